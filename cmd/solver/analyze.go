@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/awsedr2023/slide-puzzle-solver/solver"
+)
+
+func runAnalyze(args []string) {
+	fs := flag.NewFlagSet("analyze", flag.ExitOnError)
+	pf := addPuzzleFlags(fs)
+	timeout := fs.Duration("timeout", 0, "give up after this long, e.g. 30s (0 means no limit)")
+	fs.Parse(args)
+
+	p := pf.read(fs.Args(), func() {
+		fmt.Println("Usage: solver analyze [-timeout <duration>] -rows <rows> -cols <cols> <numbers...>")
+		fmt.Println("       solver analyze [-rows <rows> -cols <cols>] -in <file|-> [-format auto|flat|grid|json] [-zero]")
+		fmt.Println("Example: solver analyze -rows 3 -cols 3 1 8 2 4 3 5 7 6 9")
+	})
+
+	ctx, cancel := withTimeout(*timeout)
+	defer cancel()
+	a, err := solver.AnalyzeContext(ctx, p.start, p.goal, p.rows, p.cols)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %v: %w", *timeout, err)
+	}
+	if err != nil {
+		exitWithError(err, p)
+	}
+
+	fmt.Printf("Optimal moves:     %d\n", a.Moves)
+	fmt.Printf("Root heuristic:    %d (gap %d)\n", a.RootHeuristic, a.HeuristicGap)
+	fmt.Printf("Optimal solutions: %d\n", a.OptimalSolutions)
+	fmt.Printf("Nodes expanded:    %d\n", a.NodesExpanded)
+	fmt.Printf("Branching factor:  %.2f near the goal, %.2f overall\n", a.BranchingFactor, a.EffectiveBranchingFactor)
+	fmt.Printf("Score:             %.2f\n", a.Score)
+	fmt.Printf("Rating:            %s\n", a.Rating)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/awsedr2023/slide-puzzle-solver/solver"
+)
+
+// batchJob is one puzzle line of a batch input.
+type batchJob struct {
+	index int
+	line  int
+	text  string
+}
+
+// batchResult is one line of batch output.
+type batchResult struct {
+	Index int `json:"index"` // position of the puzzle among the input puzzles, from 0
+	Line  int `json:"line"`  // line number in the input, from 1
+	result
+}
+
+// batchSummary counts the outcomes of a batch.
+type batchSummary struct {
+	total, solved, unsolvable, invalid, timedOut, failed int
+}
+
+func (s *batchSummary) add(r batchResult) {
+	s.total++
+	if r.Error == nil {
+		s.solved++
+		return
+	}
+	switch r.Error.Code {
+	case codeUnsolvable:
+		s.unsolvable++
+	case codeInvalidInput:
+		s.invalid++
+	case codeTimeout:
+		s.timedOut++
+	default:
+		s.failed++
+	}
+}
+
+func runBatch(args []string) {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	rows := fs.Int("rows", 0, "number of rows for plain text lines")
+	cols := fs.Int("cols", 0, "number of columns for plain text lines")
+	in := fs.String("in", "-", "read puzzles from a file, or - for stdin")
+	zero := fs.Bool("zero", false, "treat 0 as the blank tile")
+	workers := fs.Int("workers", runtime.NumCPU(), "number of puzzles solved concurrently")
+	timeout := fs.Duration("timeout", 0, "time limit per puzzle, e.g. 10s (0 means no limit)")
+	order := fs.String("order", "input", "output order: input or completion")
+	withBoards := fs.Bool("boards", false, "include the intermediate boards in the results")
+	cf := addCacheFlags(fs)
+	tablebases := addTablebaseFlag(fs)
+	fs.Parse(args)
+
+	if *order != "input" && *order != "completion" || *workers < 1 {
+		fmt.Println("Usage: solver batch [-rows <rows> -cols <cols>] [-in <file|->] [-workers <n>] [-timeout <duration>] [-order input|completion] [-boards] [-zero] [-cache <n>] [-cache-file <file>] [-tablebase <files>]")
+		fmt.Println("Each input line is a JSON puzzle like {\"rows\":3,\"cols\":3,\"start\":[...]} or start (and goal) numbers for -rows x -cols.")
+		os.Exit(exitError)
+	}
+
+	var r io.Reader = os.Stdin
+	if *in != "-" {
+		f, err := os.Open(*in)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	cache, err := cf.open()
+	if err == nil {
+		err = loadTablebases(*tablebases)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(exitError)
+	}
+	if cache != nil {
+		defer cache.Close()
+	}
+
+	started := time.Now()
+	jobs := make(chan batchJob)
+	results := make(chan batchResult)
+
+	var wg sync.WaitGroup
+	for range *workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				results <- solveBatchJob(job, *rows, *cols, *zero, *timeout, *withBoards, cache)
+			}
+		}()
+	}
+
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- readBatch(r, jobs)
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	var summary batchSummary
+	out := bufio.NewWriter(os.Stdout)
+	pending := make(map[int]batchResult)
+	next := 0
+	for res := range results {
+		summary.add(res)
+		if *order == "completion" {
+			writeJSON(out, res)
+			out.Flush()
+			continue
+		}
+		pending[res.Index] = res
+		for ; ; next++ {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			writeJSON(out, r)
+		}
+		out.Flush()
+	}
+
+	if err := <-readErr; err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
+		os.Exit(exitError)
+	}
+	fmt.Fprintf(os.Stderr, "%d puzzles: %d solved, %d unsolvable, %d invalid, %d timed out, %d failed in %v\n",
+		summary.total, summary.solved, summary.unsolvable, summary.invalid, summary.timedOut, summary.failed,
+		time.Since(started).Round(time.Millisecond))
+	if cache != nil {
+		cs := cache.Stats()
+		fmt.Fprintf(os.Stderr, "cache: %d hits (%d from file), %d misses\n", cs.Hits, cs.DiskHits, cs.Misses)
+	}
+}
+
+// readBatch sends every non-empty line of r to jobs.
+func readBatch(r io.Reader, jobs chan<- batchJob) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	index := 0
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		jobs <- batchJob{index: index, line: line, text: text}
+		index++
+	}
+	return scanner.Err()
+}
+
+// solveBatchJob parses and solves one batch line.
+func solveBatchJob(job batchJob, rows, cols int, zero bool, timeout time.Duration, withBoards bool, cache *solver.Cache) batchResult {
+	res := batchResult{Index: job.index, Line: job.line}
+
+	format := "flat"
+	if strings.HasPrefix(job.text, "{") {
+		format, rows, cols = "json", 0, 0
+	}
+	p, err := parsePuzzle(job.text, format, rows, cols)
+	if err != nil {
+		res.result = newResult(p, nil, nil, 0, inputError{err}, false)
+		return res
+	}
+	if err := p.normalize(zero); err != nil {
+		res.result = newResult(p, nil, nil, 0, err, false)
+		return res
+	}
+
+	var stats solver.Stats
+	path, elapsed, err := solvePuzzle(p, timeout, solver.SolveOptions{Stats: &stats, Cache: cache})
+	res.result = newResult(p, path, &stats, elapsed, err, withBoards)
+	return res
+}
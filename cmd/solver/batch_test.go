@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSolveBatchJob(t *testing.T) {
+	input := strings.Join([]string{
+		"1 2 3 4 5 6 7 9 8",
+		`{"rows": -2, "cols": 3, "start": [1, 2]}`,
+		"# a comment",
+		`{"rows": 100000, "cols": 100000, "start": [1, 2]}`,
+		"",
+		"2 1 3 4 5 6 7 8 9",
+		"1 2 x",
+		`{"rows": 2, "cols": 2, "start": [1, 2, 4, 3]}`,
+	}, "\n")
+	want := []struct {
+		line int
+		code string // empty when solved
+	}{
+		{1, ""},
+		{2, codeInvalidInput},
+		{4, codeInvalidInput},
+		{6, codeUnsolvable},
+		{7, codeInvalidInput},
+		{8, ""},
+	}
+
+	jobs := make(chan batchJob)
+	go func() {
+		if err := readBatch(strings.NewReader(input), jobs); err != nil {
+			t.Errorf("readBatch() error = %v", err)
+		}
+		close(jobs)
+	}()
+	var summary batchSummary
+	i := 0
+	for job := range jobs {
+		if i == len(want) {
+			t.Fatalf("unexpected job for line %d", job.line)
+		}
+		res := solveBatchJob(job, 3, 3, false, 0, false, nil)
+		summary.add(res)
+		code := ""
+		if res.Error != nil {
+			code = res.Error.Code
+		}
+		if res.Index != i || res.Line != want[i].line || code != want[i].code {
+			t.Errorf("result %d = line %d, code %q; want line %d, code %q", res.Index, res.Line, code, want[i].line, want[i].code)
+		}
+		i++
+	}
+	if i != len(want) {
+		t.Fatalf("got %d results, want %d", i, len(want))
+	}
+	if summary != (batchSummary{total: 6, solved: 2, unsolvable: 1, invalid: 3}) {
+		t.Errorf("summary = %+v", summary)
+	}
+}
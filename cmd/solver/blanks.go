@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/awsedr2023/slide-puzzle-solver/solver"
+)
+
+// solveMultiBlank solves p with the given number of blanks and prints the tile moves in
+// the given output format, exiting on failure.
+func solveMultiBlank(p puzzle, blanks int, timeout time.Duration, output string) {
+	ctx, cancel := withTimeout(timeout)
+	defer cancel()
+	var stats solver.Stats
+	started := time.Now()
+	tileMoves, err := solver.SolveMultiBlank(ctx, p.start, p.goal, p.rows, p.cols, blanks, solver.SolveOptions{Stats: &stats, Wrap: p.wrap})
+	elapsed := time.Since(started)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %v: %w", timeout, err)
+	}
+
+	if output == "json" {
+		r := result{Status: "solved", Rows: p.rows, Cols: p.cols, Start: p.start, Goal: p.goal, Blanks: blanks,
+			Stats: &resultStats{Stats: stats, ElapsedMS: float64(elapsed.Microseconds()) / 1000}}
+		if p.wrap != solver.NoWrap {
+			r.Wrap = p.wrap.String()
+		}
+		if err != nil {
+			r.Status, r.Error = "error", newResultError(err, p)
+			writeJSON(os.Stdout, r)
+			_, status := classify(err)
+			os.Exit(status)
+		}
+		moves := describeTileMoves(tileMoves, p.cols)
+		count, tiles := len(moves), tileNotation(moves)
+		r.Moves, r.TileMoves, r.TileNotation = &count, moves, &tiles
+		writeJSON(os.Stdout, r)
+		return
+	}
+
+	if err != nil {
+		exitWithError(err, p)
+	}
+	fmt.Printf("Solved in %d moves:\n", len(tileMoves))
+	for i, m := range describeTileMoves(tileMoves, p.cols) {
+		fmt.Printf("%d: Move tile %d %s\n", i+1, m.Tile, strings.ToUpper(m.Direction[:1])+m.Direction[1:])
+	}
+}
+
+// describeTileMoves converts the tile moves of a solution with several blanks into moves
+// with directions. The blank of each move is the cell the tile leaves.
+func describeTileMoves(tileMoves []solver.TileMove, cols int) []move {
+	moves := make([]move, len(tileMoves))
+	for i, m := range tileMoves {
+		moves[i] = move{Tile: m.Tile, Direction: direction(m.To, m.From, cols), Blank: m.From}
+	}
+	return moves
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/awsedr2023/slide-puzzle-solver/solver"
+)
+
+// defaultCacheEntries is the in-memory capacity of a file-backed cache when -cache is not given.
+const defaultCacheEntries = 1000
+
+// cacheFlags are the command-line flags that enable the solution cache.
+type cacheFlags struct {
+	entries *int
+	file    *string
+}
+
+func addCacheFlags(fs *flag.FlagSet) *cacheFlags {
+	return &cacheFlags{
+		entries: fs.Int("cache", 0, "number of solutions cached in memory (0 disables the cache unless -cache-file is given)"),
+		file:    fs.String("cache-file", "", "append solutions to this file and reuse them across runs"),
+	}
+}
+
+// open returns the cache selected by the flags, or nil when caching is disabled.
+func (f *cacheFlags) open() (*solver.Cache, error) {
+	entries := *f.entries
+	if *f.file == "" {
+		if entries <= 0 {
+			return nil, nil
+		}
+		return solver.NewCache(entries), nil
+	}
+	if entries <= 0 {
+		entries = defaultCacheEntries
+	}
+	return solver.OpenCache(*f.file, entries)
+}
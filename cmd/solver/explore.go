@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/awsedr2023/slide-puzzle-solver/solver"
+)
+
+func runExplore(args []string) {
+	fs := flag.NewFlagSet("explore", flag.ExitOnError)
+	rows := fs.Int("rows", 0, "number of rows")
+	cols := fs.Int("cols", 0, "number of columns")
+	format := fs.String("format", "text", "output format: text, csv (histogram only) or json")
+	antipodes := fs.Int("antipodes", solver.DefaultMaxAntipodes, "maximum number of antipodes listed")
+	out := fs.String("out", "-", "write the report to a file, or - for stdout")
+	zero := fs.Bool("zero", false, "treat 0 as the blank tile in the goal")
+	dir := fs.String("dir", "", "keep the search in this directory instead of in memory, resuming it if interrupted")
+	runSize := fs.Int("run-size", solver.DefaultRunSize, "number of boards sorted in memory at once with -dir")
+	fs.Parse(args)
+
+	if *rows <= 0 || *cols <= 0 || (*format != "text" && *format != "csv" && *format != "json") {
+		fmt.Println("Usage: solver explore -rows <rows> -cols <cols> [-format text|csv|json] [-antipodes <n>] [-out <file>] [-dir <dir>] [goal numbers...]")
+		fmt.Println("Example: solver explore -rows 3 -cols 3 -format csv -out 3x3.csv")
+		fmt.Println("Example: solver explore -rows 4 -cols 4 -dir 4x4")
+		os.Exit(exitError)
+	}
+
+	if err := checkShape(*rows, *cols); err != nil {
+		exitWithError(err, puzzle{rows: *rows, cols: *cols})
+	}
+	p := puzzle{rows: *rows, cols: *cols, goal: solver.StandardGoal(*rows, *cols)}
+	if fs.NArg() > 0 {
+		goal, err := parseBoard(fs.Args())
+		if err != nil {
+			exitWithError(inputError{err}, p)
+		}
+		p.goal = goal
+		if *zero {
+			p.convertZeroBlank()
+		}
+		if err := solver.Validate(p.goal, p.rows, p.cols); err != nil {
+			exitWithError(fmt.Errorf("goal board: %w", err), p)
+		}
+	}
+
+	opts := solver.ExploreOptions{Rows: p.rows, Cols: p.cols, Goal: p.goal, MaxAntipodes: *antipodes, Dir: *dir, RunSize: *runSize}
+	if *antipodes == 0 {
+		opts.MaxAntipodes = -1
+	}
+	if *dir != "" {
+		// Searches on disk run for hours; show that they advance.
+		opts.Progress = func(distance, count int) {
+			fmt.Fprintf(os.Stderr, "distance %d: %d states\n", distance, count)
+		}
+	}
+	e, err := solver.Explore(context.Background(), opts)
+	if err != nil {
+		exitWithError(err, p)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			exitWithError(err, p)
+		}
+		defer f.Close()
+		w = f
+	}
+	switch *format {
+	case "csv":
+		err = writeHistogramCSV(w, e.Counts)
+	case "json":
+		writeJSON(w, e)
+	default:
+		writeExploration(w, e)
+	}
+	if err != nil {
+		exitWithError(err, p)
+	}
+}
+
+// writeHistogramCSV writes the number of states at each distance as CSV with a header.
+func writeHistogramCSV(w io.Writer, counts []int) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"distance", "states"})
+	for d, c := range counts {
+		cw.Write([]string{strconv.Itoa(d), strconv.Itoa(c)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeExploration prints the histogram, the summary and the antipodes.
+func writeExploration(w io.Writer, e solver.Exploration) {
+	fmt.Fprintln(w, "Distance  States")
+	for d, c := range e.Counts {
+		fmt.Fprintf(w, "%8d  %d\n", d, c)
+	}
+	fmt.Fprintf(w, "States:        %d\n", e.States)
+	fmt.Fprintf(w, "Max distance:  %d\n", e.MaxDistance)
+	fmt.Fprintf(w, "Antipodes:     %d\n", e.Counts[e.MaxDistance])
+	for _, a := range e.Antipodes {
+		fmt.Fprintf(w, "  %v\n", a)
+	}
+	if len(e.Antipodes) < e.Counts[e.MaxDistance] {
+		fmt.Fprintf(w, "  ... (%d listed, see -antipodes)\n", len(e.Antipodes))
+	}
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/awsedr2023/slide-puzzle-solver/solver"
+)
+
+// puzzle is a start board, a goal board and the shape they share.
+type puzzle struct {
+	rows  int
+	cols  int
+	start []int
+	goal  []int // nil means the standard goal
+	wrap  solver.Wrap
+	// multiset allows tiles with the same number; see solver.ValidateMultiset.
+	multiset bool
+	// metric is what counts as one move in the solution.
+	metric solver.Metric
+}
+
+// puzzleFlags are the flags shared by the commands that take a single puzzle.
+type puzzleFlags struct {
+	rows   *int
+	cols   *int
+	in     *string
+	format *string
+	zero   *bool
+	// multiset is registered by the commands that accept tiles with the same number.
+	multiset *bool
+}
+
+func addPuzzleFlags(fs *flag.FlagSet) *puzzleFlags {
+	return &puzzleFlags{
+		rows:   fs.Int("rows", 0, "number of rows"),
+		cols:   fs.Int("cols", 0, "number of columns"),
+		in:     fs.String("in", "", "read the puzzle from a file, or - for stdin"),
+		format: fs.String("format", "auto", "input format for -in: auto, flat, grid or json"),
+		zero:   fs.Bool("zero", false, "treat 0 as the blank tile"),
+	}
+}
+
+// errUsage is returned by load when the flags and arguments do not describe a puzzle.
+var errUsage = errors.New("no puzzle given")
+
+// inputError marks an error caused by malformed input.
+type inputError struct {
+	err error
+}
+
+func (e inputError) Error() string {
+	return e.err.Error()
+}
+
+func (e inputError) Unwrap() error {
+	return e.err
+}
+
+// load returns the puzzle given by -in or by the positional arguments.
+// The standard goal is filled in when no goal is given.
+func (f *puzzleFlags) load(args []string) (puzzle, error) {
+	var p puzzle
+	var err error
+	if *f.in == "" {
+		if *f.rows < 2 || *f.cols < 2 {
+			return puzzle{}, errUsage
+		}
+		p, err = readPuzzle(args, *f.rows, *f.cols)
+	} else {
+		p, err = readPuzzleFile(*f.in, *f.format, *f.rows, *f.cols)
+	}
+	if err != nil {
+		return puzzle{}, inputError{err}
+	}
+	p.multiset = f.multiset != nil && *f.multiset
+
+	return p, p.normalize(*f.zero)
+}
+
+// maxCells bounds the number of cells of a puzzle read from the input, and so what the
+// input can make the solver allocate.
+const maxCells = 10000
+
+// checkShape rejects shapes with fewer than 2 rows or columns or more than maxCells cells.
+func checkShape(rows, cols int) error {
+	if rows < 2 || cols < 2 || rows > maxCells/cols {
+		return inputError{fmt.Errorf("%w: %dx%d, expected at least 2 rows and columns and at most %d cells", solver.ErrInvalidSize, rows, cols, maxCells)}
+	}
+	return nil
+}
+
+// normalize converts a 0 blank when zero is set, fills in the standard goal when no goal
+// is given and validates both boards.
+func (p *puzzle) normalize(zero bool) error {
+	if err := checkShape(p.rows, p.cols); err != nil {
+		return err
+	}
+	if zero {
+		p.convertZeroBlank()
+	}
+	if p.goal == nil {
+		p.goal = solver.StandardGoal(p.rows, p.cols)
+	}
+
+	if p.multiset {
+		return solver.ValidateMultiset(p.start, p.goal, p.rows, p.cols)
+	}
+
+	if err := solver.Validate(p.start, p.rows, p.cols); err != nil {
+		return fmt.Errorf("start board: %w", err)
+	}
+	if err := solver.Validate(p.goal, p.rows, p.cols); err != nil {
+		return fmt.Errorf("goal board: %w", err)
+	}
+	return nil
+}
+
+// read is like load but calls usage or reports the error and exits when no valid puzzle is given.
+func (f *puzzleFlags) read(args []string, usage func()) puzzle {
+	p, err := f.load(args)
+	if errors.Is(err, errUsage) {
+		usage()
+		os.Exit(exitError)
+	}
+	if err != nil {
+		exitWithError(err, p)
+	}
+	return p
+}
+
+// readPuzzle parses the start board and the optional goal board from the positional arguments.
+func readPuzzle(args []string, rows, cols int) (puzzle, error) {
+	totalCells := rows * cols
+	if len(args) != totalCells && len(args) != 2*totalCells {
+		return puzzle{}, fmt.Errorf("expected %d (start) or %d (start followed by goal) numbers, got %d", totalCells, 2*totalCells, len(args))
+	}
+
+	start, err := parseBoard(args[:totalCells])
+	if err != nil {
+		return puzzle{}, fmt.Errorf("parsing start board: %w", err)
+	}
+
+	var goal []int
+	if len(args) == 2*totalCells {
+		goal, err = parseBoard(args[totalCells:])
+		if err != nil {
+			return puzzle{}, fmt.Errorf("parsing goal board: %w", err)
+		}
+	}
+	return puzzle{rows: rows, cols: cols, start: start, goal: goal}, nil
+}
+
+// readPuzzleFile reads a puzzle from the named file, or from stdin when name is "-".
+func readPuzzleFile(name, format string, rows, cols int) (puzzle, error) {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return puzzle{}, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return puzzle{}, err
+	}
+	return parsePuzzle(string(data), format, rows, cols)
+}
+
+// parsePuzzle parses a puzzle in one of the text formats:
+//
+//	flat: whitespace-separated numbers, the start optionally followed by the goal; needs rows and cols
+//	grid: one board row per line; a blank line separates the start from the optional goal
+//	json: {"rows": 3, "cols": 3, "start": [...], "goal": [...]} with flat or nested boards
+//
+// The auto format picks json for input starting with '{', grid for multi-line input and flat otherwise.
+// Rows and cols are only required where the format cannot infer them; when given, they must match.
+func parsePuzzle(text, format string, rows, cols int) (puzzle, error) {
+	if format == "auto" {
+		format = detectFormat(text)
+	}
+
+	var p puzzle
+	var err error
+	switch format {
+	case "flat":
+		p, err = parseFlat(text, rows, cols)
+	case "grid":
+		p, err = parseGrid(text)
+	case "json":
+		p, err = parseJSON(text)
+	default:
+		return puzzle{}, fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		return puzzle{}, err
+	}
+
+	if (rows != 0 && rows != p.rows) || (cols != 0 && cols != p.cols) {
+		return puzzle{}, fmt.Errorf("input is %dx%d, but -rows %d -cols %d was given", p.rows, p.cols, rows, cols)
+	}
+	return p, nil
+}
+
+// detectFormat guesses the format of a puzzle for parsePuzzle.
+func detectFormat(text string) string {
+	text = strings.TrimSpace(text)
+	switch {
+	case strings.HasPrefix(text, "{"):
+		return "json"
+	case strings.Contains(text, "\n"):
+		return "grid"
+	default:
+		return "flat"
+	}
+}
+
+func parseFlat(text string, rows, cols int) (puzzle, error) {
+	if rows < 2 || cols < 2 {
+		return puzzle{}, errors.New("flat input needs -rows and -cols")
+	}
+	fields := strings.Fields(text)
+	totalCells := rows * cols
+	if len(fields) != totalCells && len(fields) != 2*totalCells {
+		return puzzle{}, fmt.Errorf("expected %d (start) or %d (start followed by goal) numbers, got %d", totalCells, 2*totalCells, len(fields))
+	}
+
+	board, err := parseBoard(fields)
+	if err != nil {
+		return puzzle{}, err
+	}
+	p := puzzle{rows: rows, cols: cols, start: board[:totalCells]}
+	if len(board) == 2*totalCells {
+		p.goal = board[totalCells:]
+	}
+	return p, nil
+}
+
+func parseGrid(text string) (puzzle, error) {
+	var blocks [][][]int
+	var block [][]int
+	for _, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			if block != nil {
+				blocks = append(blocks, block)
+				block = nil
+			}
+			continue
+		}
+		row, err := parseBoard(fields)
+		if err != nil {
+			return puzzle{}, err
+		}
+		block = append(block, row)
+	}
+	if block != nil {
+		blocks = append(blocks, block)
+	}
+
+	if len(blocks) == 0 || len(blocks) > 2 {
+		return puzzle{}, fmt.Errorf("expected a start grid and an optional goal grid, got %d grids", len(blocks))
+	}
+	boards := make([][]int, len(blocks))
+	rows, cols := len(blocks[0]), len(blocks[0][0])
+	for i, b := range blocks {
+		board, r, c, err := flattenGrid(b)
+		if err != nil {
+			return puzzle{}, err
+		}
+		if r != rows || c != cols {
+			return puzzle{}, fmt.Errorf("goal grid is %dx%d, but start grid is %dx%d", r, c, rows, cols)
+		}
+		boards[i] = board
+	}
+
+	p := puzzle{rows: rows, cols: cols, start: boards[0]}
+	if len(boards) == 2 {
+		p.goal = boards[1]
+	}
+	return p, nil
+}
+
+// flattenGrid joins the rows of a grid into a board, checking that all rows have the same length.
+func flattenGrid(grid [][]int) (board []int, rows, cols int, err error) {
+	rows, cols = len(grid), len(grid[0])
+	for i, row := range grid {
+		if len(row) != cols {
+			return nil, 0, 0, fmt.Errorf("row %d has %d numbers, expected %d", i+1, len(row), cols)
+		}
+		board = append(board, row...)
+	}
+	return board, rows, cols, nil
+}
+
+// jsonPuzzle is the JSON form of a puzzle. Boards are flat arrays or arrays of rows.
+type jsonPuzzle struct {
+	Rows  int             `json:"rows"`
+	Cols  int             `json:"cols"`
+	Start json.RawMessage `json:"start"`
+	Goal  json.RawMessage `json:"goal"`
+}
+
+func parseJSON(text string) (puzzle, error) {
+	var jp jsonPuzzle
+	if err := json.Unmarshal([]byte(text), &jp); err != nil {
+		return puzzle{}, err
+	}
+	return jp.puzzle()
+}
+
+// puzzle decodes the boards of jp.
+func (jp jsonPuzzle) puzzle() (puzzle, error) {
+	if jp.Start == nil {
+		return puzzle{}, errors.New(`missing "start"`)
+	}
+
+	p := puzzle{rows: jp.Rows, cols: jp.Cols}
+	var err error
+	if p.start, err = decodeJSONBoard(jp.Start, &p.rows, &p.cols); err != nil {
+		return puzzle{}, fmt.Errorf("start: %w", err)
+	}
+	if jp.Goal != nil {
+		if p.goal, err = decodeJSONBoard(jp.Goal, &p.rows, &p.cols); err != nil {
+			return puzzle{}, fmt.Errorf("goal: %w", err)
+		}
+	}
+	if p.rows == 0 || p.cols == 0 {
+		return puzzle{}, errors.New(`flat boards need "rows" and "cols"`)
+	}
+	return p, nil
+}
+
+// decodeJSONBoard decodes a flat or nested board. A nested board sets rows and cols
+// when they are unset and must match them otherwise.
+func decodeJSONBoard(raw json.RawMessage, rows, cols *int) ([]int, error) {
+	var flat []int
+	if err := json.Unmarshal(raw, &flat); err == nil {
+		return flat, nil
+	}
+
+	var grid [][]int
+	if err := json.Unmarshal(raw, &grid); err != nil {
+		return nil, errors.New("expected an array of numbers or an array of rows")
+	}
+	if len(grid) == 0 {
+		return nil, errors.New("empty board")
+	}
+	board, r, c, err := flattenGrid(grid)
+	if err != nil {
+		return nil, err
+	}
+	if (*rows != 0 && *rows != r) || (*cols != 0 && *cols != c) {
+		return nil, fmt.Errorf("board is %dx%d, expected %dx%d", r, c, *rows, *cols)
+	}
+	*rows, *cols = r, c
+	return board, nil
+}
+
+// convertZeroBlank replaces the 0 used as the blank by the common convention
+// with rows*cols, the blank used by the solver.
+func (p *puzzle) convertZeroBlank() {
+	blank := p.rows * p.cols
+	for _, board := range [][]int{p.start, p.goal} {
+		for i, v := range board {
+			if v == 0 {
+				board[i] = blank
+			}
+		}
+	}
+}
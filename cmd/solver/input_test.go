@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/awsedr2023/slide-puzzle-solver/solver"
+)
+
+func TestParsePuzzle(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		format    string
+		rows      int
+		cols      int
+		wantRows  int
+		wantCols  int
+		wantStart []int
+		wantGoal  []int
+		wantErr   bool
+	}{
+		{"flat", "1 2 4 3", "auto", 2, 2, 2, 2, []int{1, 2, 4, 3}, nil, false},
+		{"flat with goal", "1 2 4 3 4 3 2 1", "flat", 2, 2, 2, 2, []int{1, 2, 4, 3}, []int{4, 3, 2, 1}, false},
+		{"flat without size", "1 2 4 3", "flat", 0, 0, 0, 0, nil, nil, true},
+		{"flat with the wrong count", "1 2 3", "flat", 2, 2, 0, 0, nil, nil, true},
+		{"grid", "1 2 3\n4 6 5\n", "auto", 0, 0, 2, 3, []int{1, 2, 3, 4, 6, 5}, nil, false},
+		{"grid with goal", "1 2\n4 3\n\n4 3\n2 1\n", "grid", 0, 0, 2, 2, []int{1, 2, 4, 3}, []int{4, 3, 2, 1}, false},
+		{"ragged grid", "1 2 3\n4 5\n", "grid", 0, 0, 0, 0, nil, nil, true},
+		{"json", `{"rows": 2, "cols": 2, "start": [1, 2, 4, 3]}`, "auto", 0, 0, 2, 2, []int{1, 2, 4, 3}, nil, false},
+		{"nested json", `{"start": [[1, 2], [4, 3]], "goal": [[4, 3], [2, 1]]}`, "json", 0, 0, 2, 2, []int{1, 2, 4, 3}, []int{4, 3, 2, 1}, false},
+		{"json without size", `{"start": [1, 2, 4, 3]}`, "json", 0, 0, 0, 0, nil, nil, true},
+		{"json without start", `{"rows": 2, "cols": 2}`, "json", 0, 0, 0, 0, nil, nil, true},
+		{"size that differs from the flags", "1 2\n4 3\n", "grid", 3, 3, 0, 0, nil, nil, true},
+		{"unknown format", "1 2 4 3", "csv", 2, 2, 0, 0, nil, nil, true},
+		{"not a number", "1 2 x 3", "flat", 2, 2, 0, 0, nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsePuzzle(tt.text, tt.format, tt.rows, tt.cols)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePuzzle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if p.rows != tt.wantRows || p.cols != tt.wantCols || !slices.Equal(p.start, tt.wantStart) || !slices.Equal(p.goal, tt.wantGoal) {
+				t.Errorf("parsePuzzle() = %dx%d %v %v, want %dx%d %v %v", p.rows, p.cols, p.start, p.goal, tt.wantRows, tt.wantCols, tt.wantStart, tt.wantGoal)
+			}
+		})
+	}
+}
+
+func TestPuzzle_Normalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		p        puzzle
+		zero     bool
+		wantGoal []int
+		wantErr  error
+	}{
+		{"standard goal", puzzle{rows: 2, cols: 2, start: []int{1, 2, 4, 3}}, false, []int{1, 2, 3, 4}, nil},
+		{"zero blank", puzzle{rows: 2, cols: 2, start: []int{1, 2, 0, 3}, goal: []int{1, 2, 3, 0}}, true, []int{1, 2, 3, 4}, nil},
+		{"invalid start", puzzle{rows: 2, cols: 2, start: []int{1, 2, 2, 3}}, false, nil, solver.ErrInvalidElement},
+		{"short start", puzzle{rows: 2, cols: 2, start: []int{1, 2}}, false, nil, solver.ErrSizeMismatch},
+		{"negative rows", puzzle{rows: -2, cols: 3, start: []int{1, 2}}, false, nil, solver.ErrInvalidSize},
+		{"one column", puzzle{rows: 2, cols: 1, start: []int{1, 2}}, false, nil, solver.ErrInvalidSize},
+		{"too many cells", puzzle{rows: 100000, cols: 100000, start: []int{1, 2}}, false, nil, solver.ErrInvalidSize},
+		{"overflowing cells", puzzle{rows: 1 << 62, cols: 4, start: []int{1, 2}}, false, nil, solver.ErrInvalidSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.p.normalize(tt.zero)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("normalize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if code, _ := classify(err); code != codeInvalidInput {
+					t.Errorf("normalize() error %v is classified as %q, want %q", err, code, codeInvalidInput)
+				}
+				return
+			}
+			if !slices.Equal(tt.p.goal, tt.wantGoal) {
+				t.Errorf("normalize() goal = %v, want %v", tt.p.goal, tt.wantGoal)
+			}
+		})
+	}
+}
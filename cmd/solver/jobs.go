@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/awsedr2023/slide-puzzle-solver/solver"
+)
+
+// jobStatus is the state of an asynchronous job.
+type jobStatus string
+
+const (
+	jobQueued    jobStatus = "queued"
+	jobRunning   jobStatus = "running"
+	jobSolved    jobStatus = "solved"
+	jobFailed    jobStatus = "failed"
+	jobCancelled jobStatus = "cancelled"
+)
+
+// finished reports whether the job will not change any more.
+func (s jobStatus) finished() bool {
+	return s == jobSolved || s == jobFailed || s == jobCancelled
+}
+
+// heartbeatInterval is the time between comments sent on an idle event stream
+// so that proxies do not close it.
+const heartbeatInterval = 15 * time.Second
+
+// job is a puzzle being solved in the background.
+type job struct {
+	id     string
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	status   jobStatus
+	progress *solver.Progress
+	result   *result
+	finished time.Time
+	changed  chan struct{} // closed and replaced on every update
+}
+
+// jobView is the JSON representation of a job.
+type jobView struct {
+	ID       string           `json:"id"`
+	Status   jobStatus        `json:"status"`
+	Progress *solver.Progress `json:"progress,omitempty"`
+	Result   *result          `json:"result,omitempty"`
+}
+
+// snapshot returns the job's current view and a channel closed on its next update.
+func (j *job) snapshot() (jobView, <-chan struct{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return jobView{ID: j.id, Status: j.status, Progress: j.progress, Result: j.result}, j.changed
+}
+
+// update applies fn to the job under its lock and wakes up the watchers.
+func (j *job) update(fn func()) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	fn()
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// jobStore keeps the asynchronous jobs in memory until their TTL has passed.
+type jobStore struct {
+	timeout time.Duration // per job, including time queued
+	ttl     time.Duration // how long finished jobs are kept
+	max     int           // jobs kept at once
+	slots   chan struct{} // one token per job being solved
+	cache   *solver.Cache // optional
+
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+// newJobStore returns a store solving up to workers jobs at once, and starts
+// removing expired jobs in the background.
+func newJobStore(workers, max int, timeout, ttl time.Duration, cache *solver.Cache) *jobStore {
+	js := &jobStore{
+		timeout: timeout,
+		ttl:     ttl,
+		max:     max,
+		slots:   make(chan struct{}, workers),
+		cache:   cache,
+		jobs:    make(map[string]*job),
+	}
+	go js.expire()
+	return js
+}
+
+// submit registers a job for p and starts solving it once a slot is free.
+func (js *jobStore) submit(p puzzle, withBoards bool) (*job, error) {
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), js.timeout)
+	j := &job{id: id, cancel: cancel, status: jobQueued, changed: make(chan struct{})}
+
+	js.mu.Lock()
+	if len(js.jobs) >= js.max {
+		js.mu.Unlock()
+		cancel()
+		return nil, &apiError{status: http.StatusServiceUnavailable, code: codeBusy, err: errors.New("too many jobs, retry later")}
+	}
+	js.jobs[id] = j
+	js.mu.Unlock()
+
+	go js.run(ctx, j, p, withBoards)
+	return j, nil
+}
+
+// run solves the job's puzzle, publishing progress as the search advances.
+func (js *jobStore) run(ctx context.Context, j *job, p puzzle, withBoards bool) {
+	defer j.cancel()
+	select {
+	case js.slots <- struct{}{}:
+		defer func() { <-js.slots }()
+	case <-ctx.Done():
+		js.finish(j, p, nil, nil, 0, ctx.Err(), withBoards)
+		return
+	}
+	j.update(func() { j.status = jobRunning })
+
+	var stats solver.Stats
+	opts := solver.SolveOptions{
+		Stats:    &stats,
+		Cache:    js.cache,
+		Progress: func(pr solver.Progress) { j.update(func() { j.progress = &pr }) },
+	}
+	started := time.Now()
+	path, err := solver.SolveWithOptions(ctx, p.start, p.goal, p.rows, p.cols, opts)
+	js.finish(j, p, path, &stats, time.Since(started), err, withBoards)
+}
+
+// finish records the outcome of a job. Cancelled jobs have no result.
+func (js *jobStore) finish(j *job, p puzzle, path []int, stats *solver.Stats, elapsed time.Duration, err error, withBoards bool) {
+	var res *result
+	status := jobCancelled
+	if !errors.Is(err, context.Canceled) {
+		r := newResult(p, path, stats, elapsed, err, withBoards)
+		res, status = &r, jobSolved
+		if err != nil {
+			status = jobFailed
+		}
+	}
+	j.update(func() {
+		j.status, j.result, j.finished = status, res, time.Now()
+	})
+}
+
+// get returns the job with the given ID.
+func (js *jobStore) get(id string) (*job, error) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	j, ok := js.jobs[id]
+	if !ok {
+		return nil, &apiError{status: http.StatusNotFound, code: codeNotFound, err: fmt.Errorf("job %q not found", id)}
+	}
+	return j, nil
+}
+
+// expire periodically removes the jobs that finished more than js.ttl ago.
+func (js *jobStore) expire() {
+	ticker := time.NewTicker(min(js.ttl, time.Minute))
+	defer ticker.Stop()
+	for now := range ticker.C {
+		js.mu.Lock()
+		for id, j := range js.jobs {
+			j.mu.Lock()
+			expired := j.status.finished() && now.Sub(j.finished) > js.ttl
+			j.mu.Unlock()
+			if expired {
+				delete(js.jobs, id)
+			}
+		}
+		js.mu.Unlock()
+	}
+}
+
+// newJobID returns a random job identifier.
+func newJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (s *server) submitJob(w http.ResponseWriter, r *http.Request) {
+	var req solveRequest
+	p, err := decodePuzzle(s.decoder(w, r), &req)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	j, err := s.jobs.submit(p, req.Boards)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	view, _ := j.snapshot()
+	w.Header().Set("Location", "/jobs/"+j.id)
+	respond(w, http.StatusAccepted, view)
+}
+
+func (s *server) getJob(w http.ResponseWriter, r *http.Request) {
+	j, err := s.jobs.get(r.PathValue("id"))
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	view, _ := j.snapshot()
+	respond(w, http.StatusOK, view)
+}
+
+// cancelJob stops a queued or running job. Finished jobs are left as they are.
+func (s *server) cancelJob(w http.ResponseWriter, r *http.Request) {
+	j, err := s.jobs.get(r.PathValue("id"))
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	j.cancel()
+	// Wait for the solver to notice, so that the response shows the final state.
+	view, changed := j.snapshot()
+	for !view.Status.finished() {
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+		view, changed = j.snapshot()
+	}
+	respond(w, http.StatusOK, view)
+}
+
+// jobEvents streams the job as server-sent events: a "progress" event for every
+// change while it is queued or running, then one "result" event once it has finished.
+func (s *server) jobEvents(w http.ResponseWriter, r *http.Request) {
+	j, err := s.jobs.get(r.PathValue("id"))
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeAPIError(w, errors.New("streaming is not supported"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		view, changed := j.snapshot()
+		if view.Status.finished() {
+			writeEvent(w, "result", view)
+			flusher.Flush()
+			return
+		}
+		writeEvent(w, "progress", view)
+		flusher.Flush()
+
+		for waiting := true; waiting; {
+			select {
+			case <-changed:
+				waiting = false
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+}
+
+// writeEvent writes one server-sent event with v encoded as JSON in its data field.
+func writeEvent(w http.ResponseWriter, event string, v any) {
+	data, _ := json.Marshal(v)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
@@ -1,83 +1,199 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/awsedr2023/slide-puzzle-solver/solver"
 )
 
 func main() {
-	rows := flag.Int("rows", 0, "number of rows")
-	cols := flag.Int("cols", 0, "number of columns")
-	flag.Parse()
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch args[0] {
+		case "analyze":
+			runAnalyze(args[1:])
+			return
+		case "batch":
+			runBatch(args[1:])
+			return
+		case "explore":
+			runExplore(args[1:])
+			return
+		case "serve":
+			runServe(args[1:])
+			return
+		case "stages":
+			runStages(args[1:])
+			return
+		case "tablebase":
+			runTablebase(args[1:])
+			return
+		}
+	}
+	runSolve(args)
+}
+
+func runSolve(args []string) {
+	fs := flag.NewFlagSet("solver", flag.ExitOnError)
+	pf := addPuzzleFlags(fs)
+	output := fs.String("output", "text", "output format: text or json")
+	withBoards := fs.Bool("boards", false, "include the intermediate boards in JSON output")
+	timeout := fs.Duration("timeout", 0, "give up after this long, e.g. 30s (0 means no limit)")
+	cf := addCacheFlags(fs)
+	tablebases := addTablebaseFlag(fs)
+	wrapName := fs.String("wrap", "none", "let moves cross the edges of the board: none, cylinder or torus")
+	blanks := fs.Int("blanks", 1, "number of blanks, the largest values of the board")
+	pf.multiset = fs.Bool("multiset", false, "allow tiles with the same number, which are interchangeable; give the goal too")
+	metricName := fs.String("metric", "stm", "what counts as one move: stm (one tile) or mtm (a row or column segment)")
+	fs.Parse(args)
+
+	if *output != "text" && *output != "json" {
+		fmt.Printf("Error: unknown output format %q\n", *output)
+		os.Exit(exitError)
+	}
+	wrap, ok := wrapModes[*wrapName]
+	if !ok {
+		fmt.Printf("Error: unknown wrap mode %q\n", *wrapName)
+		os.Exit(exitError)
+	}
+	metric, ok := metrics[*metricName]
+	if !ok {
+		fmt.Printf("Error: unknown metric %q\n", *metricName)
+		os.Exit(exitError)
+	}
 
-	if *rows < 2 || *cols < 2 {
+	p, err := pf.load(fs.Args())
+	p.wrap, p.metric = wrap, metric
+	if errors.Is(err, errUsage) {
 		fmt.Println("Usage: solver -rows <rows> -cols <cols> <numbers...>")
+		fmt.Println("       solver [-rows <rows> -cols <cols>] -in <file|-> [-format auto|flat|grid|json] [-zero]")
+		fmt.Println("       solver analyze -rows <rows> -cols <cols> <numbers...>")
+		fmt.Println("       solver batch [-rows <rows> -cols <cols>] [-in <file|->] [-workers <n>] [-timeout <duration>]")
+		fmt.Println("       solver serve [-addr <addr>] [-timeout <duration>] [-concurrency <n>]")
+		fmt.Println("       solver tablebase -rows <rows> -cols <cols> -out <file> [goal numbers...]")
+		fmt.Println("       solver explore -rows <rows> -cols <cols> [-format text|csv|json] [goal numbers...]")
+		fmt.Println("       solver stages -rows <rows> -cols <cols> [-stage <board>]... [-stages <file>] <numbers...>")
+		fmt.Println("Options: -output text|json, -boards, -timeout <duration>, -cache <n>, -cache-file <file>, -tablebase <files>, -wrap none|cylinder|torus, -blanks <n>, -multiset, -metric stm|mtm")
 		fmt.Println("Example: solver -rows 3 -cols 3 1 2 3 4 5 6 7 8 9")
 		fmt.Println("Example with custom goal: solver -rows 2 -cols 2 1 2 4 3 1 2 3 4")
-		os.Exit(1)
+		fmt.Println("Example with 8 and 9 as blanks: solver -rows 3 -cols 3 -blanks 2 2 1 3 4 5 6 7 8 9")
+		fmt.Println("Example with colored tiles: solver -rows 2 -cols 2 -multiset 2 1 1 4 1 1 2 4")
+		os.Exit(exitError)
 	}
 
-	args := flag.Args()
-	totalCells := *rows * *cols
-	if len(args) != totalCells && len(args) != 2*totalCells {
-		fmt.Printf("Error: Expected %d (start) or %d (start followed by goal) numbers, got %d\n", totalCells, 2*totalCells, len(args))
-		os.Exit(1)
+	if (*blanks != 1 || p.multiset) && metric != solver.SingleTile {
+		fmt.Println("Error: -metric mtm cannot be combined with -blanks or -multiset")
+		os.Exit(exitError)
+	}
+	if err == nil && *blanks != 1 {
+		if p.multiset {
+			fmt.Println("Error: -blanks and -multiset cannot be combined")
+			os.Exit(exitError)
+		}
+		solveMultiBlank(p, *blanks, *timeout, *output)
+		return
 	}
 
-	input, err := parseBoard(args[:totalCells])
-	if err != nil {
-		fmt.Printf("Error parsing start board: %v\n", err)
-		os.Exit(1)
+	cache, cacheErr := cf.open()
+	if cacheErr == nil {
+		cacheErr = loadTablebases(*tablebases)
+	}
+	if cacheErr != nil {
+		fmt.Printf("Error: %v\n", cacheErr)
+		os.Exit(exitError)
+	}
+	if cache != nil {
+		defer cache.Close()
 	}
 
-	var goal []int
-	if len(args) == 2*totalCells {
-		goal, err = parseBoard(args[totalCells:])
+	var path []int
+	var stats *solver.Stats
+	var elapsed time.Duration
+	if err == nil {
+		stats = &solver.Stats{}
+		path, elapsed, err = solvePuzzle(p, *timeout, solver.SolveOptions{Stats: stats, Cache: cache, Wrap: wrap, Metric: metric})
+	}
+
+	if *output == "json" {
+		writeJSON(os.Stdout, newResult(p, path, stats, elapsed, err, *withBoards))
 		if err != nil {
-			fmt.Printf("Error parsing goal board: %v\n", err)
-			os.Exit(1)
+			_, status := classify(err)
+			os.Exit(status)
 		}
-	} else {
-		goal = solver.StandardGoal(*rows, *cols)
+		return
 	}
 
-	path, err := solver.Solve(input, goal, *rows, *cols)
 	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
+		exitWithError(err, p)
 	}
 
-	fmt.Printf("Solved in %d moves:\n", len(path)-1)
+	segments := solver.Segments(path, p.cols)
+	if metric == solver.MultiTile {
+		fmt.Printf("Solved in %d multi-tile moves (%d single-tile moves):\n", len(segments), len(path)-1)
+		for i, m := range groupMoves(describeMoves(p.start, path, p.cols), segments) {
+			fmt.Printf("%d: Move %s %s\n", i+1, m.tiles(), strings.ToUpper(m.Direction[:1])+m.Direction[1:])
+		}
+		return
+	}
 
-	board := make([]int, len(input))
-	copy(board, input)
+	switch {
+	case stats.CacheHit:
+		fmt.Printf("Solved in %d moves (from cache):\n", len(path)-1)
+	case stats.TablebaseHit:
+		fmt.Printf("Solved in %d moves (from tablebase):\n", len(path)-1)
+	default:
+		fmt.Printf("Solved in %d moves:\n", len(path)-1)
+	}
+	for i, m := range describeMoves(p.start, path, p.cols) {
+		fmt.Printf("%d: Move tile %d %s\n", i+1, m.Tile, strings.ToUpper(m.Direction[:1])+m.Direction[1:])
+	}
+	fmt.Printf("%d moves in the multi-tile metric\n", len(segments))
+}
 
-	blankIdx := path[0]
-	for i, nextBlank := range path[1:] {
-		tile := board[nextBlank]
+// wrapModes maps the values of -wrap to the modes they select.
+var wrapModes = map[string]solver.Wrap{
+	"none":     solver.NoWrap,
+	"cylinder": solver.Cylinder,
+	"torus":    solver.Torus,
+}
 
-		r1, c1 := blankIdx / *cols, blankIdx%*cols
-		r2, c2 := nextBlank / *cols, nextBlank%*cols
+// metrics maps the values of -metric to the metrics they select.
+var metrics = map[string]solver.Metric{
+	"stm": solver.SingleTile,
+	"mtm": solver.MultiTile,
+}
 
-		var dir string
-		if r2 < r1 {
-			dir = "Down"
-		} else if r2 > r1 {
-			dir = "Up"
-		} else if c2 < c1 {
-			dir = "Right"
-		} else {
-			dir = "Left"
-		}
+// solvePuzzle solves p within timeout (no limit when zero) with the given options.
+func solvePuzzle(p puzzle, timeout time.Duration, opts solver.SolveOptions) ([]int, time.Duration, error) {
+	ctx, cancel := withTimeout(timeout)
+	defer cancel()
+
+	solve := solver.SolveWithOptions
+	if p.multiset {
+		solve = solver.SolveMultiset
+	}
+	started := time.Now()
+	path, err := solve(ctx, p.start, p.goal, p.rows, p.cols, opts)
+	elapsed := time.Since(started)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %v: %w", timeout, err)
+	}
+	return path, elapsed, err
+}
 
-		fmt.Printf("%d: Move tile %d %s\n", i+1, tile, dir)
-		board[blankIdx], board[nextBlank] = board[nextBlank], board[blankIdx]
-		blankIdx = nextBlank
+// withTimeout returns a context that expires after timeout, or never when timeout is zero.
+func withTimeout(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(context.Background(), timeout)
 	}
+	return context.WithCancel(context.Background())
 }
 
 func parseBoard(strs []string) ([]int, error) {
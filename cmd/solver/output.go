@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/awsedr2023/slide-puzzle-solver/solver"
+)
+
+// Exit statuses distinguish the kinds of failure for scripts.
+const (
+	exitError        = 1 // usage errors and unexpected failures
+	exitInvalidInput = 2
+	exitUnsolvable   = 3
+	exitTimeout      = 4
+)
+
+// Error codes are the stable identifiers of failures in JSON output.
+const (
+	codeError        = "error"
+	codeInvalidInput = "invalid_input"
+	codeUnsolvable   = "unsolvable"
+	codeTimeout      = "timeout"
+)
+
+// invalidInputErrors are the solver errors caused by the puzzle, options or files given.
+var invalidInputErrors = []error{
+	solver.ErrEmptyBoard,
+	solver.ErrInvalidSize,
+	solver.ErrSizeMismatch,
+	solver.ErrInvalidElement,
+	solver.ErrLockedMask,
+	solver.ErrLockedTile,
+	solver.ErrLockedBlank,
+	solver.ErrInvalidRange,
+	solver.ErrNoPuzzles,
+	solver.ErrInvalidRank,
+	solver.ErrInvalidPermutation,
+	solver.ErrInvalidShape,
+	solver.ErrInvalidGraph,
+	solver.ErrInvalidPartialGoal,
+	solver.ErrInvalidBlanks,
+	solver.ErrLabelMismatch,
+	solver.ErrUnsupportedOption,
+	solver.ErrExploreTooLarge,
+	solver.ErrTablebaseTooLarge,
+	solver.ErrInvalidTablebase,
+}
+
+// classify maps an error to its JSON error code and exit status.
+func classify(err error) (code string, status int) {
+	var ie inputError
+	switch {
+	case errors.Is(err, solver.ErrUnsolvable):
+		return codeUnsolvable, exitUnsolvable
+	case errors.Is(err, context.DeadlineExceeded):
+		return codeTimeout, exitTimeout
+	case errors.As(err, &ie), slices.ContainsFunc(invalidInputErrors, func(target error) bool { return errors.Is(err, target) }):
+		return codeInvalidInput, exitInvalidInput
+	default:
+		return codeError, exitError
+	}
+}
+
+// exitWithError prints a solver error and exits with the status for its kind.
+// For unsolvable puzzles on ordinary boards it also prints the parity argument and a
+// swap that would make the puzzle solvable.
+func exitWithError(err error, p puzzle) {
+	fmt.Printf("Error: %v\n", err)
+	if errors.Is(err, solver.ErrUnsolvable) && p.wrap == solver.NoWrap && !p.multiset {
+		if s, err := solver.CheckSolvability(p.start, p.goal, p.rows, p.cols); err == nil {
+			fmt.Println(s)
+		}
+	}
+	_, status := classify(err)
+	os.Exit(status)
+}
+
+// move is one step of a solution.
+type move struct {
+	Tile int `json:"tile"`
+	// Direction is the direction the tile slides: up, down, left or right.
+	Direction string `json:"direction"`
+	// Blank is the index of the blank after the move.
+	Blank int `json:"blank"`
+}
+
+// describeMoves converts a path of blank indices into the tile moves it makes.
+// A blank that jumps to the opposite edge of its row or column has wrapped around, so
+// the tile slides the other way across the edge.
+func describeMoves(start, path []int, cols int) []move {
+	board := make([]int, len(start))
+	copy(board, start)
+
+	moves := make([]move, 0, len(path)-1)
+	blankIdx := path[0]
+	for _, nextBlank := range path[1:] {
+		moves = append(moves, move{Tile: board[nextBlank], Direction: direction(blankIdx, nextBlank, cols), Blank: nextBlank})
+		board[blankIdx], board[nextBlank] = board[nextBlank], board[blankIdx]
+		blankIdx = nextBlank
+	}
+	return moves
+}
+
+// direction returns the direction a tile slides when the blank moves from blankIdx to the
+// neighboring cell nextBlank.
+func direction(blankIdx, nextBlank, cols int) string {
+	switch from, to := blankIdx%cols, nextBlank%cols; {
+	case from != to && (to == from+1 || to < from-1):
+		return "left"
+	case from != to:
+		return "right"
+	case nextBlank == blankIdx+cols || nextBlank < blankIdx-cols:
+		return "up"
+	default:
+		return "down"
+	}
+}
+
+// segment is a move of the multi-tile metric: tiles of a row or column sliding together.
+type segment struct {
+	// Tiles lists the tiles that slide, starting with the one next to the blank.
+	Tiles     []int  `json:"tiles"`
+	Direction string `json:"direction"`
+	// Blank is the index of the blank after the move.
+	Blank int `json:"blank"`
+}
+
+// tiles describes the tiles of the segment, like "tile 5" or "tiles 7 8".
+func (s segment) tiles() string {
+	if len(s.Tiles) == 1 {
+		return fmt.Sprintf("tile %d", s.Tiles[0])
+	}
+	parts := make([]string, len(s.Tiles))
+	for i, t := range s.Tiles {
+		parts[i] = fmt.Sprint(t)
+	}
+	return "tiles " + strings.Join(parts, " ")
+}
+
+// groupMoves groups the moves of a path into the segments returned by solver.Segments for it.
+func groupMoves(moves []move, segments [][]int) []segment {
+	groups := make([]segment, len(segments))
+	i := 0
+	for k, cells := range segments {
+		g := segment{Direction: moves[i].Direction, Blank: cells[len(cells)-1]}
+		for range cells[1:] {
+			g.Tiles = append(g.Tiles, moves[i].Tile)
+			i++
+		}
+		groups[k] = g
+	}
+	return groups
+}
+
+// blankNotation writes the moves of the blank as a string of U, D, L and R.
+// The blank moves in the opposite direction of the tile it swaps with.
+func blankNotation(moves []move) string {
+	opposite := map[string]byte{"up": 'D', "down": 'U', "left": 'R', "right": 'L'}
+	var b strings.Builder
+	for _, m := range moves {
+		b.WriteByte(opposite[m.Direction])
+	}
+	return b.String()
+}
+
+// tileNotation writes the moves as space-separated tiles and directions, like "5D 3R".
+func tileNotation(moves []move) string {
+	parts := make([]string, len(moves))
+	for i, m := range moves {
+		parts[i] = fmt.Sprintf("%d%c", m.Tile, strings.ToUpper(m.Direction)[0])
+	}
+	return strings.Join(parts, " ")
+}
+
+// pathFromBlankMoves converts moves of the blank written as U, D, L and R into
+// a path of blank indices starting at the blank of p.start.
+func pathFromBlankMoves(p puzzle, moves string) ([]int, error) {
+	blankIdx := slices.Index(p.start, p.rows*p.cols)
+	path := []int{blankIdx}
+	for i, m := range strings.ToUpper(moves) {
+		r, c := blankIdx/p.cols, blankIdx%p.cols
+		switch m {
+		case 'U':
+			r--
+		case 'D':
+			r++
+		case 'L':
+			c--
+		case 'R':
+			c++
+		default:
+			return nil, fmt.Errorf("move %d: unknown direction %q", i+1, m)
+		}
+		if r < 0 || r >= p.rows || c < 0 || c >= p.cols {
+			return nil, fmt.Errorf("move %d: %c moves the blank off the board", i+1, m)
+		}
+		blankIdx = r*p.cols + c
+		path = append(path, blankIdx)
+	}
+	return path, nil
+}
+
+// verifyPath replays a path of blank indices from p.start and reports why it is not
+// a solution leading to p.goal, if it is not.
+func verifyPath(p puzzle, path []int) error {
+	if blankIdx := slices.Index(p.start, p.rows*p.cols); path[0] != blankIdx {
+		return fmt.Errorf("path starts at %d, but the blank is at %d", path[0], blankIdx)
+	}
+	for i := 1; i < len(path); i++ {
+		from, to := path[i-1], path[i]
+		if to < 0 || to >= len(p.start) {
+			return fmt.Errorf("move %d: index %d is off the board", i, to)
+		}
+		sameRow := from/p.cols == to/p.cols
+		if !(sameRow && (to == from-1 || to == from+1)) && to != from-p.cols && to != from+p.cols {
+			return fmt.Errorf("move %d: %d is not adjacent to %d", i, to, from)
+		}
+	}
+
+	boards := boardsAlong(p.start, path)
+	if !slices.Equal(boards[len(boards)-1], p.goal) {
+		return errors.New("the path does not reach the goal")
+	}
+	return nil
+}
+
+// boardsAlong returns the boards visited by a path of blank indices, starting with start.
+func boardsAlong(start, path []int) [][]int {
+	boards := [][]int{start}
+	board := start
+	for i := 1; i < len(path); i++ {
+		next := make([]int, len(board))
+		copy(next, board)
+		next[path[i-1]], next[path[i]] = next[path[i]], next[path[i-1]]
+		boards = append(boards, next)
+		board = next
+	}
+	return boards
+}
+
+// result is the JSON document printed by -output json.
+type result struct {
+	Status   string `json:"status"` // "solved" or "error"
+	Rows     int    `json:"rows,omitempty"`
+	Cols     int    `json:"cols,omitempty"`
+	Start    []int  `json:"start,omitempty"`
+	Goal     []int  `json:"goal,omitempty"`
+	Wrap     string `json:"wrap,omitempty"`     // "cylinder" or "torus" when moves wrap around
+	Blanks   int    `json:"blanks,omitempty"`   // number of blanks when there are several
+	Multiset bool   `json:"multiset,omitempty"` // whether tiles may share numbers
+	Metric   string `json:"metric,omitempty"`   // "mtm" when solved in the multi-tile metric
+
+	Moves        *int    `json:"moves,omitempty"`
+	Path         []int   `json:"path,omitempty"` // blank indices, including the initial position
+	TileMoves    []move  `json:"tile_moves,omitempty"`
+	TileNotation *string `json:"tile_notation,omitempty"`
+	BlankMoves   *string `json:"blank_moves,omitempty"`
+	Boards       [][]int `json:"boards,omitempty"`
+	// MultiTileMoves is the length of the solution in the multi-tile metric, whose moves
+	// Segments lists when the solution is shortest in that metric.
+	MultiTileMoves *int      `json:"multi_tile_moves,omitempty"`
+	Segments       []segment `json:"segments,omitempty"`
+
+	Stats *resultStats `json:"stats,omitempty"`
+	Error *resultError `json:"error,omitempty"`
+}
+
+type resultStats struct {
+	solver.Stats
+	ElapsedMS float64 `json:"elapsed_ms"`
+}
+
+type resultError struct {
+	Code        string                  `json:"code"`
+	Message     string                  `json:"message"`
+	Validation  *solver.ValidationError `json:"validation,omitempty"`
+	Solvability *solver.Solvability     `json:"solvability,omitempty"`
+}
+
+// newResult builds the JSON document for a solve attempt.
+func newResult(p puzzle, path []int, stats *solver.Stats, elapsed time.Duration, err error, withBoards bool) result {
+	r := result{Status: "solved", Rows: p.rows, Cols: p.cols, Start: p.start, Goal: p.goal}
+	if p.wrap != solver.NoWrap {
+		r.Wrap = p.wrap.String()
+	}
+	r.Multiset = p.multiset
+	if stats != nil {
+		r.Stats = &resultStats{Stats: *stats, ElapsedMS: float64(elapsed.Microseconds()) / 1000}
+	}
+	if err != nil {
+		r.Status = "error"
+		r.Error = newResultError(err, p)
+		return r
+	}
+
+	moves := describeMoves(p.start, path, p.cols)
+	count, tiles, blanks := len(moves), tileNotation(moves), blankNotation(moves)
+	r.Moves, r.Path, r.TileMoves, r.TileNotation, r.BlankMoves = &count, path, moves, &tiles, &blanks
+	segments := solver.Segments(path, p.cols)
+	multiTile := len(segments)
+	r.MultiTileMoves = &multiTile
+	if p.metric == solver.MultiTile {
+		r.Metric, r.Segments = p.metric.String(), groupMoves(moves, segments)
+	}
+	if withBoards {
+		r.Boards = boardsAlong(p.start, path)
+	}
+	return r
+}
+
+// newResultError describes err with its stable code and any structured details.
+func newResultError(err error, p puzzle) *resultError {
+	code, _ := classify(err)
+	e := &resultError{Code: code, Message: err.Error()}
+	errors.As(err, &e.Validation)
+	if code == codeUnsolvable && p.wrap == solver.NoWrap && !p.multiset {
+		if s, err := solver.CheckSolvability(p.start, p.goal, p.rows, p.cols); err == nil {
+			e.Solvability = &s
+		}
+	}
+	return e
+}
+
+// writeJSON prints v as JSON on a single line.
+func writeJSON(w io.Writer, v any) {
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/awsedr2023/slide-puzzle-solver/solver"
+)
+
+// solverErrors lists every exported error of the solver package with its expected code.
+var solverErrors = map[string]struct {
+	err  error
+	code string
+}{
+	"ErrEmptyBoard":         {solver.ErrEmptyBoard, codeInvalidInput},
+	"ErrInvalidSize":        {solver.ErrInvalidSize, codeInvalidInput},
+	"ErrSizeMismatch":       {solver.ErrSizeMismatch, codeInvalidInput},
+	"ErrInvalidElement":     {solver.ErrInvalidElement, codeInvalidInput},
+	"ErrLockedMask":         {solver.ErrLockedMask, codeInvalidInput},
+	"ErrLockedTile":         {solver.ErrLockedTile, codeInvalidInput},
+	"ErrLockedBlank":        {solver.ErrLockedBlank, codeInvalidInput},
+	"ErrInvalidRange":       {solver.ErrInvalidRange, codeInvalidInput},
+	"ErrNoPuzzles":          {solver.ErrNoPuzzles, codeInvalidInput},
+	"ErrInvalidRank":        {solver.ErrInvalidRank, codeInvalidInput},
+	"ErrInvalidPermutation": {solver.ErrInvalidPermutation, codeInvalidInput},
+	"ErrInvalidShape":       {solver.ErrInvalidShape, codeInvalidInput},
+	"ErrInvalidGraph":       {solver.ErrInvalidGraph, codeInvalidInput},
+	"ErrInvalidPartialGoal": {solver.ErrInvalidPartialGoal, codeInvalidInput},
+	"ErrInvalidBlanks":      {solver.ErrInvalidBlanks, codeInvalidInput},
+	"ErrLabelMismatch":      {solver.ErrLabelMismatch, codeInvalidInput},
+	"ErrExploreTooLarge":    {solver.ErrExploreTooLarge, codeInvalidInput},
+	"ErrTablebaseTooLarge":  {solver.ErrTablebaseTooLarge, codeInvalidInput},
+	"ErrInvalidTablebase":   {solver.ErrInvalidTablebase, codeInvalidInput},
+	"ErrUnsupportedOption":  {solver.ErrUnsupportedOption, codeInvalidInput},
+	"ErrUnsolvable":         {solver.ErrUnsolvable, codeUnsolvable},
+}
+
+func TestClassify_SolverErrors(t *testing.T) {
+	for name, tt := range solverErrors {
+		t.Run(name, func(t *testing.T) {
+			wantStatus := map[string]int{codeInvalidInput: exitInvalidInput, codeUnsolvable: exitUnsolvable}[tt.code]
+			// Solver errors reach classify wrapped with details.
+			code, status := classify(fmt.Errorf("start board: %w", tt.err))
+			if code != tt.code || status != wantStatus {
+				t.Errorf("classify(%v) = %q, %d; want %q, %d", tt.err, code, status, tt.code, wantStatus)
+			}
+		})
+	}
+}
+
+// TestClassify_AllSolverErrors checks that solverErrors covers every exported error
+// variable declared in the solver package, so that new ones get a code.
+func TestClassify_AllSolverErrors(t *testing.T) {
+	files, err := filepath.Glob("../../solver/*.go")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no solver sources found: %v", err)
+	}
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), name, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				for _, ident := range spec.(*ast.ValueSpec).Names {
+					if _, ok := solverErrors[ident.Name]; strings.HasPrefix(ident.Name, "Err") && !ok {
+						t.Errorf("solver.%s is missing from solverErrors", ident.Name)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   string
+		wantStatus int
+	}{
+		{"input error", inputError{errors.New("bad number")}, codeInvalidInput, exitInvalidInput},
+		{"timeout", fmt.Errorf("timed out: %w", context.DeadlineExceeded), codeTimeout, exitTimeout},
+		{"other", errors.New("disk full"), codeError, exitError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, status := classify(tt.err); code != tt.wantCode || status != tt.wantStatus {
+				t.Errorf("classify() = %q, %d; want %q, %d", code, status, tt.wantCode, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+	"slices"
+	"time"
+
+	"github.com/awsedr2023/slide-puzzle-solver/solver"
+)
+
+// Error codes used only by the HTTP service.
+const (
+	codeBusy       = "busy"
+	codeBadRequest = "bad_request"
+	codeNotFound   = "not_found"
+)
+
+// maxGenerateCount bounds the number of puzzles one /generate request may ask for.
+const maxGenerateCount = 100
+
+// maxGenerateMoves bounds the solution lengths one /generate request may ask for.
+const maxGenerateMoves = 200
+
+// server answers the HTTP JSON API.
+type server struct {
+	timeout time.Duration // per request
+	maxBody int64         // bytes
+	slots   chan struct{} // one token per request being served
+	jobs    *jobStore
+	cache   *solver.Cache // optional
+}
+
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "address to listen on")
+	timeout := fs.Duration("timeout", 30*time.Second, "time limit per request")
+	maxBody := fs.Int64("max-body", 64<<10, "maximum request body size in bytes")
+	concurrency := fs.Int("concurrency", runtime.NumCPU(), "maximum number of requests served at once; more get 503")
+	jobWorkers := fs.Int("jobs", runtime.NumCPU(), "maximum number of asynchronous jobs solved at once; more are queued")
+	maxJobs := fs.Int("max-jobs", 1000, "maximum number of asynchronous jobs kept; more get 503")
+	jobTimeout := fs.Duration("job-timeout", 10*time.Minute, "time limit per asynchronous job, including time queued")
+	jobTTL := fs.Duration("job-ttl", 10*time.Minute, "how long finished jobs are kept")
+	cf := addCacheFlags(fs)
+	tablebases := addTablebaseFlag(fs)
+	fs.Parse(args)
+
+	if *concurrency < 1 || *maxBody < 1 || *timeout <= 0 || *jobWorkers < 1 || *maxJobs < 1 || *jobTimeout <= 0 || *jobTTL <= 0 {
+		fmt.Println("Usage: solver serve [-addr <addr>] [-timeout <duration>] [-max-body <bytes>] [-concurrency <n>]")
+		fmt.Println("                    [-jobs <n>] [-max-jobs <n>] [-job-timeout <duration>] [-job-ttl <duration>]")
+		fmt.Println("                    [-cache <n>] [-cache-file <file>] [-tablebase <files>]")
+		os.Exit(exitError)
+	}
+
+	cache, err := cf.open()
+	if err == nil {
+		err = loadTablebases(*tablebases)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s := &server{
+		timeout: *timeout,
+		maxBody: *maxBody,
+		slots:   make(chan struct{}, *concurrency),
+		jobs:    newJobStore(*jobWorkers, *maxJobs, *jobTimeout, *jobTTL, cache),
+		cache:   cache,
+	}
+	log.Printf("listening on %s", *addr)
+	if err := http.ListenAndServe(*addr, s.routes()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// routes returns the handler for every endpoint.
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("POST /solve", s.endpoint(s.solve))
+	mux.Handle("POST /verify", s.endpoint(s.verify))
+	mux.Handle("POST /generate", s.endpoint(s.generate))
+	mux.Handle("POST /hint", s.endpoint(s.hint))
+	mux.HandleFunc("POST /jobs", s.submitJob)
+	mux.HandleFunc("GET /jobs/{id}", s.getJob)
+	mux.HandleFunc("GET /jobs/{id}/events", s.jobEvents)
+	mux.HandleFunc("DELETE /jobs/{id}", s.cancelJob)
+	mux.HandleFunc("GET /cache", s.cacheStats)
+	return mux
+}
+
+// apiError is an error with the HTTP status and code it is reported with.
+type apiError struct {
+	status int
+	code   string
+	err    error
+	result *result // response body; built from err when nil
+}
+
+func (e *apiError) Error() string {
+	return e.err.Error()
+}
+
+func (e *apiError) Unwrap() error {
+	return e.err
+}
+
+func badRequest(err error) *apiError {
+	return &apiError{status: http.StatusBadRequest, code: codeBadRequest, err: err}
+}
+
+// endpoint wraps a handler function with the concurrency limit, the request timeout,
+// the body size limit and JSON encoding of the response or error.
+func (s *server) endpoint(handle func(ctx context.Context, body *json.Decoder) (any, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case s.slots <- struct{}{}:
+			defer func() { <-s.slots }()
+		default:
+			writeAPIError(w, &apiError{status: http.StatusServiceUnavailable, code: codeBusy, err: errors.New("server is busy, retry later")})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+		defer cancel()
+
+		resp, err := handle(ctx, s.decoder(w, r))
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		respond(w, http.StatusOK, resp)
+	})
+}
+
+// decoder returns a strict JSON decoder for the request body, limited to s.maxBody bytes.
+func (s *server) decoder(w http.ResponseWriter, r *http.Request) *json.Decoder {
+	body := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxBody))
+	body.DisallowUnknownFields()
+	return body
+}
+
+// respond writes v as the JSON response body with the given status.
+func respond(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	writeJSON(w, v)
+}
+
+// writeAPIError reports err with an HTTP status derived from its kind.
+func writeAPIError(w http.ResponseWriter, err error) {
+	ae := toAPIError(err)
+	res := ae.result
+	if res == nil {
+		e := &resultError{Code: ae.code, Message: err.Error()}
+		errors.As(err, &e.Validation)
+		res = &result{Status: "error", Error: e}
+	}
+	respond(w, ae.status, res)
+}
+
+// toAPIError returns err as an *apiError, deriving the status and code from its kind
+// unless it already is one.
+func toAPIError(err error) *apiError {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return &apiError{status: http.StatusRequestEntityTooLarge, code: codeBadRequest, err: err}
+	}
+	var ae *apiError
+	if errors.As(err, &ae) {
+		return ae
+	}
+
+	code, _ := classify(err)
+	status := http.StatusInternalServerError
+	switch code {
+	case codeInvalidInput:
+		status = http.StatusBadRequest
+	case codeUnsolvable:
+		status = http.StatusUnprocessableEntity
+	case codeTimeout:
+		status = http.StatusGatewayTimeout
+	}
+	return &apiError{status: status, code: code, err: err}
+}
+
+// decodePuzzle decodes a request body holding a puzzle and extra fields into req,
+// returning the normalized puzzle found in its embedded jsonPuzzle.
+func decodePuzzle(body *json.Decoder, req interface{ puzzleFields() *puzzleRequest }) (puzzle, error) {
+	if err := body.Decode(req); err != nil {
+		return puzzle{}, badRequest(err)
+	}
+	pr := req.puzzleFields()
+	p, err := pr.jsonPuzzle.puzzle()
+	if err != nil {
+		return puzzle{}, inputError{err}
+	}
+	if err := checkShape(p.rows, p.cols); err != nil {
+		return puzzle{}, badRequest(err)
+	}
+	return p, p.normalize(pr.Zero)
+}
+
+// puzzleRequest holds the puzzle fields shared by the requests.
+type puzzleRequest struct {
+	jsonPuzzle
+	Zero bool `json:"zero"` // treat 0 as the blank tile
+}
+
+func (r *puzzleRequest) puzzleFields() *puzzleRequest {
+	return r
+}
+
+type solveRequest struct {
+	puzzleRequest
+	Boards bool `json:"boards"`
+}
+
+func (s *server) solve(ctx context.Context, body *json.Decoder) (any, error) {
+	var req solveRequest
+	p, err := decodePuzzle(body, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats solver.Stats
+	started := time.Now()
+	path, err := solver.SolveWithOptions(ctx, p.start, p.goal, p.rows, p.cols, solver.SolveOptions{Stats: &stats, Cache: s.cache})
+	res := newResult(p, path, &stats, time.Since(started), err, req.Boards)
+	if err != nil {
+		ae := toAPIError(err)
+		ae.result = &res
+		return nil, ae
+	}
+	return res, nil
+}
+
+// cacheStats reports the hit and miss counts of the solution cache.
+func (s *server) cacheStats(w http.ResponseWriter, r *http.Request) {
+	if s.cache == nil {
+		writeAPIError(w, &apiError{status: http.StatusNotFound, code: codeNotFound, err: errors.New("the cache is disabled")})
+		return
+	}
+	respond(w, http.StatusOK, s.cache.Stats())
+}
+
+type verifyRequest struct {
+	puzzleRequest
+	// Either Path (blank indices, including the initial position) or BlankMoves (U, D, L and R) is given.
+	Path       []int  `json:"path"`
+	BlankMoves string `json:"blank_moves"`
+}
+
+type verifyResponse struct {
+	Valid  bool   `json:"valid"`
+	Moves  int    `json:"moves"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func (s *server) verify(ctx context.Context, body *json.Decoder) (any, error) {
+	var req verifyRequest
+	p, err := decodePuzzle(body, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	path := req.Path
+	if req.BlankMoves != "" {
+		if path != nil {
+			return nil, badRequest(errors.New(`give either "path" or "blank_moves"`))
+		}
+		if path, err = pathFromBlankMoves(p, req.BlankMoves); err != nil {
+			return verifyResponse{Reason: err.Error()}, nil
+		}
+	}
+	if len(path) == 0 {
+		return nil, badRequest(errors.New(`missing "path" or "blank_moves"`))
+	}
+
+	if err := verifyPath(p, path); err != nil {
+		return verifyResponse{Moves: len(path) - 1, Reason: err.Error()}, nil
+	}
+	return verifyResponse{Valid: true, Moves: len(path) - 1}, nil
+}
+
+type generateRequest struct {
+	Rows     int   `json:"rows"`
+	Cols     int   `json:"cols"`
+	Goal     []int `json:"goal"`
+	Count    int   `json:"count"`
+	MinMoves int   `json:"min_moves"`
+	MaxMoves int   `json:"max_moves"`
+	Unique   bool  `json:"unique"`
+	// ExcludeSymmetric also rejects mirror images of generated puzzles.
+	ExcludeSymmetric bool `json:"exclude_symmetric"`
+}
+
+type generatedPuzzle struct {
+	Start []int `json:"start"`
+	Moves int   `json:"moves"`
+}
+
+func (s *server) generate(ctx context.Context, body *json.Decoder) (any, error) {
+	var req generateRequest
+	if err := body.Decode(&req); err != nil {
+		return nil, badRequest(err)
+	}
+	if req.Count < 1 || req.Count > maxGenerateCount {
+		return nil, badRequest(fmt.Errorf(`"count" must be between 1 and %d`, maxGenerateCount))
+	}
+	if req.MinMoves < 0 || req.MaxMoves < req.MinMoves || req.MaxMoves > maxGenerateMoves {
+		return nil, badRequest(fmt.Errorf(`"min_moves" and "max_moves" must satisfy 0 <= min_moves <= max_moves <= %d`, maxGenerateMoves))
+	}
+	if err := checkShape(req.Rows, req.Cols); err != nil {
+		return nil, badRequest(err)
+	}
+
+	puzzles, err := solver.Generate(ctx, solver.GenerateOptions{
+		Rows:             req.Rows,
+		Cols:             req.Cols,
+		Goal:             req.Goal,
+		Count:            req.Count,
+		MinMoves:         req.MinMoves,
+		MaxMoves:         req.MaxMoves,
+		Workers:          1,
+		Unique:           req.Unique,
+		ExcludeSymmetric: req.ExcludeSymmetric,
+	})
+	if errors.Is(err, solver.ErrInvalidRange) || errors.Is(err, solver.ErrNoPuzzles) {
+		return nil, inputError{err}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]generatedPuzzle, len(puzzles))
+	for i, p := range puzzles {
+		resp[i] = generatedPuzzle{Start: p.Board, Moves: p.Moves}
+	}
+	return struct {
+		Puzzles []generatedPuzzle `json:"puzzles"`
+	}{resp}, nil
+}
+
+type hintResponse struct {
+	Distance  int    `json:"distance"`
+	Moves     []int  `json:"moves"` // blank indices after each optimal move
+	TileMoves []move `json:"tile_moves"`
+}
+
+func (s *server) hint(ctx context.Context, body *json.Decoder) (any, error) {
+	var req puzzleRequest
+	p, err := decodePuzzle(body, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := solver.HintContext(ctx, p.start, p.goal, p.rows, p.cols)
+	if err != nil {
+		return nil, err
+	}
+	resp := hintResponse{Distance: h.Distance, Moves: h.Moves, TileMoves: []move{}}
+	if resp.Moves == nil {
+		resp.Moves = []int{}
+	}
+	blankIdx := slices.Index(p.start, p.rows*p.cols)
+	for _, next := range h.Moves {
+		resp.TileMoves = append(resp.TileMoves, describeMoves(p.start, []int{blankIdx, next}, p.cols)...)
+	}
+	return resp, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServer returns a server with room for a few requests and jobs.
+func newTestServer() *server {
+	return &server{
+		timeout: 10 * time.Second,
+		maxBody: 64 << 10,
+		slots:   make(chan struct{}, 4),
+		jobs:    newJobStore(2, 10, 10*time.Second, time.Minute, nil),
+	}
+}
+
+func TestServer_Shapes(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"solve", "/solve", `{"rows": 2, "cols": 2, "start": [1, 2, 4, 3]}`, http.StatusOK, ""},
+		{"solve with negative rows", "/solve", `{"rows": -1, "cols": 3, "start": [1, 2]}`, http.StatusBadRequest, codeBadRequest},
+		{"solve with too many cells", "/solve", `{"rows": 100000, "cols": 100000, "start": [1, 2]}`, http.StatusBadRequest, codeBadRequest},
+		{"verify with negative rows", "/verify", `{"rows": -1, "cols": 3, "start": [1, 2], "path": [0]}`, http.StatusBadRequest, codeBadRequest},
+		{"hint with too many cells", "/hint", `{"rows": 100000, "cols": 100000, "start": [1, 2]}`, http.StatusBadRequest, codeBadRequest},
+		{"generate", "/generate", `{"rows": 2, "cols": 2, "count": 1, "min_moves": 2, "max_moves": 2}`, http.StatusOK, ""},
+		{"generate with negative rows", "/generate", `{"rows": -1, "cols": 3, "count": 1}`, http.StatusBadRequest, codeBadRequest},
+		{"generate with too many cells", "/generate", `{"rows": 100000, "cols": 100000, "count": 1}`, http.StatusBadRequest, codeBadRequest},
+		{"generate with negative moves", "/generate", `{"rows": 2, "cols": 2, "count": 1, "min_moves": -1, "max_moves": 2}`, http.StatusBadRequest, codeBadRequest},
+		{"generate with a reversed range", "/generate", `{"rows": 2, "cols": 2, "count": 1, "min_moves": 3, "max_moves": 2}`, http.StatusBadRequest, codeBadRequest},
+		{"generate with too many moves", "/generate", `{"rows": 3, "cols": 3, "count": 1, "max_moves": 4611686018427387904}`, http.StatusBadRequest, codeBadRequest},
+		{"generate beyond the largest distance", "/generate", `{"rows": 2, "cols": 2, "count": 1, "min_moves": 7, "max_moves": 7}`, http.StatusBadRequest, codeInvalidInput},
+		{"job", "/jobs", `{"rows": 2, "cols": 2, "start": [1, 2, 4, 3]}`, http.StatusAccepted, ""},
+		{"job with negative rows", "/jobs", `{"rows": -1, "cols": 3, "start": [1, 2]}`, http.StatusBadRequest, codeBadRequest},
+		{"job with too many cells", "/jobs", `{"rows": 100000, "cols": 100000, "start": [1, 2]}`, http.StatusBadRequest, codeBadRequest},
+	}
+	handler := newTestServer().routes()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d; body %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			var res result
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+			if res.Error == nil || res.Error.Code != tt.wantCode {
+				t.Errorf("error = %+v, want code %q", res.Error, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/awsedr2023/slide-puzzle-solver/solver"
+)
+
+func runStages(args []string) {
+	fs := flag.NewFlagSet("stages", flag.ExitOnError)
+	pf := addPuzzleFlags(fs)
+	var specs []string
+	fs.Func("stage", "a partial goal board with _ for any tile; repeat for each stage", func(s string) error {
+		specs = append(specs, s)
+		return nil
+	})
+	stagesFile := fs.String("stages", "", "read the stages from a JSON file: [{\"cells\": [...], \"groups\": [...]}, ...]")
+	output := fs.String("output", "text", "output format: text or json")
+	timeout := fs.Duration("timeout", 0, "give up after this long, e.g. 30s (0 means no limit)")
+	fs.Parse(args)
+
+	if *output != "text" && *output != "json" {
+		fmt.Printf("Error: unknown output format %q\n", *output)
+		os.Exit(exitError)
+	}
+	p := pf.read(fs.Args(), func() {
+		fmt.Println("Usage: solver stages -rows <rows> -cols <cols> [-stage <board>]... [-stages <file>] <numbers...>")
+		fmt.Println("Without stages, the rows and columns of the goal are solved one at a time down to 3x3.")
+		fmt.Println("Example: solver stages -rows 3 -cols 3 -stage \"1 2 3 _ _ _ _ _ _\" 4 1 3 7 2 6 5 8 9")
+	})
+
+	stages, err := readStages(specs, *stagesFile, p, *pf.zero)
+	if err != nil {
+		exitWithStagesError(err, *output)
+	}
+
+	ctx, cancel := withTimeout(*timeout)
+	defer cancel()
+	var stats solver.Stats
+	started := time.Now()
+	s, err := solver.SolveStages(ctx, p.start, stages, p.rows, p.cols, solver.SolveOptions{Stats: &stats})
+	elapsed := time.Since(started)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %v: %w", *timeout, err)
+		}
+		exitWithStagesError(err, *output)
+	}
+
+	board := p.start
+	if *output == "json" {
+		r := stagesResult{Status: "solved", Rows: p.rows, Cols: p.cols, Start: p.start, Moves: &s.Moves, Path: s.Path,
+			Stats: &resultStats{Stats: stats, ElapsedMS: float64(elapsed.Microseconds()) / 1000}}
+		for _, stage := range s.Stages {
+			r.Stages = append(r.Stages, stageResult{Goal: stage.Goal, Moves: stage.Moves, Path: stage.Path, TileMoves: describeMoves(board, stage.Path, p.cols)})
+			board = boardsAlong(board, stage.Path)[stage.Moves]
+		}
+		writeJSON(os.Stdout, r)
+		return
+	}
+
+	fmt.Printf("Solved in %d moves in %d stages:\n", s.Moves, len(s.Stages))
+	n := 0
+	for i, stage := range s.Stages {
+		fmt.Printf("Stage %d: %d moves\n", i+1, stage.Moves)
+		for _, m := range describeMoves(board, stage.Path, p.cols) {
+			n++
+			fmt.Printf("%d: Move tile %d %s\n", n, m.Tile, strings.ToUpper(m.Direction[:1])+m.Direction[1:])
+		}
+		board = boardsAlong(board, stage.Path)[stage.Moves]
+	}
+}
+
+// readStages returns the stages given by -stage and -stages, or StandardStages of the
+// puzzle's goal when there are none.
+func readStages(specs []string, file string, p puzzle, zero bool) ([]solver.PartialGoal, error) {
+	var stages []solver.PartialGoal
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &stages); err != nil {
+			return nil, inputError{fmt.Errorf("%s: %w", file, err)}
+		}
+	}
+	for i, spec := range specs {
+		cells, err := parseStage(spec, p.rows*p.cols, zero)
+		if err != nil {
+			return nil, inputError{fmt.Errorf("stage %d: %w", len(stages)+i+1, err)}
+		}
+		stages = append(stages, solver.PartialGoal{Cells: cells})
+	}
+	if len(stages) == 0 {
+		return solver.StandardStages(p.goal, p.rows, p.cols)
+	}
+	return stages, nil
+}
+
+// parseStage parses a partial goal board of n cells: numbers separated by spaces or
+// commas, with _ for cells that may hold any tile.
+func parseStage(spec string, n int, zero bool) ([]int, error) {
+	fields := strings.FieldsFunc(spec, func(r rune) bool { return r == ' ' || r == ',' || r == '\t' || r == '\n' })
+	if len(fields) != n {
+		return nil, fmt.Errorf("expected %d cells, got %d", n, len(fields))
+	}
+	cells := make([]int, n)
+	for i, f := range fields {
+		if f == "_" {
+			cells[i] = solver.Wildcard
+			continue
+		}
+		v, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s'", f)
+		}
+		if zero && v == 0 {
+			v = n
+		}
+		if v == solver.Wildcard {
+			return nil, errors.New("0 is not a tile; use _ for any tile or -zero for the blank")
+		}
+		cells[i] = v
+	}
+	return cells, nil
+}
+
+// exitWithStagesError prints err in the requested output format and exits with its status.
+func exitWithStagesError(err error, output string) {
+	code, status := classify(err)
+	if output == "json" {
+		writeJSON(os.Stdout, stagesResult{Status: "error", Error: &resultError{Code: code, Message: err.Error()}})
+	} else {
+		fmt.Printf("Error: %v\n", err)
+	}
+	os.Exit(status)
+}
+
+// stagesResult is the JSON document printed by stages -output json.
+type stagesResult struct {
+	Status string        `json:"status"` // "solved" or "error"
+	Rows   int           `json:"rows,omitempty"`
+	Cols   int           `json:"cols,omitempty"`
+	Start  []int         `json:"start,omitempty"`
+	Moves  *int          `json:"moves,omitempty"`
+	Path   []int         `json:"path,omitempty"` // blank indices of all stages, including the initial position
+	Stages []stageResult `json:"stages,omitempty"`
+	Stats  *resultStats  `json:"stats,omitempty"`
+	Error  *resultError  `json:"error,omitempty"`
+}
+
+type stageResult struct {
+	Goal      solver.PartialGoal `json:"goal"`
+	Moves     int                `json:"moves"`
+	Path      []int              `json:"path"`
+	TileMoves []move             `json:"tile_moves"`
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/awsedr2023/slide-puzzle-solver/solver"
+)
+
+func runTablebase(args []string) {
+	fs := flag.NewFlagSet("tablebase", flag.ExitOnError)
+	rows := fs.Int("rows", 0, "number of rows")
+	cols := fs.Int("cols", 0, "number of columns")
+	out := fs.String("out", "", "file to write the tablebase to")
+	zero := fs.Bool("zero", false, "treat 0 as the blank tile in the goal")
+	fs.Parse(args)
+
+	if *rows <= 0 || *cols <= 0 || *out == "" {
+		fmt.Println("Usage: solver tablebase -rows <rows> -cols <cols> -out <file> [goal numbers...]")
+		fmt.Printf("Boards of up to %d cells are supported, e.g. 3x3 and 2x5.\n", solver.MaxTablebaseCells)
+		fmt.Println("Example: solver tablebase -rows 3 -cols 3 -out 3x3.tb")
+		os.Exit(exitError)
+	}
+
+	if err := checkShape(*rows, *cols); err != nil {
+		exitWithError(err, puzzle{rows: *rows, cols: *cols})
+	}
+	p := puzzle{rows: *rows, cols: *cols, goal: solver.StandardGoal(*rows, *cols)}
+	if fs.NArg() > 0 {
+		goal, err := parseBoard(fs.Args())
+		if err != nil {
+			exitWithError(inputError{err}, p)
+		}
+		p.goal = goal
+		if *zero {
+			p.convertZeroBlank()
+		}
+		if err := solver.Validate(p.goal, p.rows, p.cols); err != nil {
+			exitWithError(fmt.Errorf("goal board: %w", err), p)
+		}
+	}
+
+	started := time.Now()
+	tb, err := solver.BuildTablebase(p.goal, p.rows, p.cols)
+	if err != nil {
+		exitWithError(err, p)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		exitWithError(err, p)
+	}
+	if _, err := tb.WriteTo(f); err != nil {
+		f.Close()
+		exitWithError(err, p)
+	}
+	if err := f.Close(); err != nil {
+		exitWithError(err, p)
+	}
+	fmt.Printf("Wrote %s: %d states, max distance %d, built in %v\n",
+		*out, tb.States(), tb.MaxDistance(), time.Since(started).Round(time.Millisecond))
+}
+
+// addTablebaseFlag registers the flag that loads tablebases for Solve and Hint.
+func addTablebaseFlag(fs *flag.FlagSet) *string {
+	return fs.String("tablebase", "", "comma-separated tablebase files (see the tablebase command) to answer matching puzzles from")
+}
+
+// loadTablebases reads and loads the comma-separated tablebase files in list.
+func loadTablebases(list string) error {
+	if list == "" {
+		return nil
+	}
+	for _, name := range strings.Split(list, ",") {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		tb, err := solver.ReadTablebase(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		solver.LoadTablebase(tb)
+	}
+	return nil
+}
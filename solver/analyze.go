@@ -0,0 +1,165 @@
+package solver
+
+import (
+	"context"
+	"math"
+)
+
+// nearGoalPlies is the number of plies before the goal over which Analyze measures the
+// branching factor.
+const nearGoalPlies = 3
+
+// Rating is a coarse difficulty bucket for a puzzle instance.
+type Rating string
+
+const (
+	RatingEasy   Rating = "easy"
+	RatingMedium Rating = "medium"
+	RatingHard   Rating = "hard"
+	RatingExpert Rating = "expert"
+)
+
+// Analysis describes how hard a puzzle instance is to search and to play.
+type Analysis struct {
+	// Moves is the optimal solution length.
+	Moves int
+	// RootHeuristic is the heuristic estimate of the start configuration.
+	RootHeuristic int
+	// HeuristicGap is Moves - RootHeuristic; larger gaps mean the heuristic is misleading.
+	HeuristicGap int
+	// OptimalSolutions is the number of distinct shortest paths.
+	OptimalSolutions int
+	// NodesExpanded is the number of nodes expanded by IDA* over all iterations.
+	NodesExpanded int
+	// BranchingFactor is the branching factor near the goal: over the last nearGoalPlies
+	// plies of the final iteration, the number of nodes within the optimal bound at one
+	// depth divided by that at the depth before.
+	BranchingFactor float64
+	// EffectiveBranchingFactor is the branching factor b* of the whole search, satisfying
+	// NodesExpanded = b* + b*^2 + ... + b*^Moves.
+	EffectiveBranchingFactor float64
+	// Score combines the metrics above; higher is harder.
+	Score float64
+	// Rating is the bucket Score falls into.
+	Rating Rating
+}
+
+// Analyze solves the puzzle and reports difficulty metrics for it.
+// It returns the same errors as Solve.
+//
+// Example:
+//
+//	a, err := Analyze([]int{1, 8, 2, 4, 3, 5, 7, 6, 9}, StandardGoal(3, 3), 3, 3)
+//	fmt.Println(a.Moves, a.Rating)
+func Analyze(start, goal []int, rows, cols int) (Analysis, error) {
+	return AnalyzeContext(context.Background(), start, goal, rows, cols)
+}
+
+// AnalyzeContext is like Analyze but gives up with ctx.Err() once ctx is done, both
+// while solving and while counting the optimal solutions, which can take longer.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+//	defer cancel()
+//	a, err := AnalyzeContext(ctx, start, goal, 4, 4)
+func AnalyzeContext(ctx context.Context, start, goal []int, rows, cols int) (Analysis, error) {
+	if err := validate(start, rows, cols); err != nil {
+		return Analysis{}, err
+	}
+	if err := validate(goal, rows, cols); err != nil {
+		return Analysis{}, err
+	}
+
+	if !isSolvable(start, goal, rows, cols) {
+		return Analysis{}, ErrUnsolvable
+	}
+
+	s := &searcher{ctx: ctx, goal: goal, rows: rows, cols: cols}
+	root := newNode(start, rows, cols)
+	found, err := s.run(root)
+	if err != nil {
+		return Analysis{}, err
+	}
+
+	a := Analysis{
+		Moves:         found.cost,
+		RootHeuristic: calculateHeuristic(start, goal, rows, cols),
+		NodesExpanded: s.expanded,
+	}
+	s.plies = make([]int, a.Moves+1)
+	a.OptimalSolutions = s.countSolutions(root, a.Moves)
+	if s.err != nil {
+		return Analysis{}, s.err
+	}
+	a.HeuristicGap = a.Moves - a.RootHeuristic
+	a.BranchingFactor = nearGoalBranchingFactor(s.plies)
+	a.EffectiveBranchingFactor = effectiveBranchingFactor(a.NodesExpanded, a.Moves)
+	a.Score = difficultyScore(a)
+	a.Rating = rate(a.Score)
+	return a, nil
+}
+
+// nearGoalBranchingFactor returns the growth of the number of nodes per depth, given in
+// plies, over the last nearGoalPlies plies, or 0 for a solved board.
+func nearGoalBranchingFactor(plies []int) float64 {
+	last := len(plies) - 1
+	if last == 0 {
+		return 0
+	}
+	first := max(last-nearGoalPlies, 0)
+	parents, children := 0, 0
+	for d := first; d < last; d++ {
+		parents += plies[d]
+		children += plies[d+1]
+	}
+	return float64(children) / float64(parents)
+}
+
+// effectiveBranchingFactor solves nodes = b + b^2 + ... + b^depth for b by bisection.
+func effectiveBranchingFactor(nodes, depth int) float64 {
+	if depth == 0 || nodes == 0 {
+		return 0
+	}
+	total := func(b float64) float64 {
+		sum, term := 0.0, 1.0
+		for range depth {
+			term *= b
+			sum += term
+		}
+		return sum
+	}
+
+	lo, hi := 0.0, float64(nodes)
+	for range 100 {
+		mid := (lo + hi) / 2
+		if total(mid) < float64(nodes) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// difficultyScore grows with the search effort and the heuristic gap and shrinks
+// with the number of optimal solutions, which make a puzzle more forgiving to play.
+func difficultyScore(a Analysis) float64 {
+	return math.Log2(float64(a.NodesExpanded+1)) +
+		float64(a.HeuristicGap)/2 -
+		math.Log2(float64(a.OptimalSolutions))
+}
+
+// rate maps a difficulty score to a rating bucket.
+func rate(score float64) Rating {
+	switch {
+	case score < 6:
+		return RatingEasy
+	case score < 12:
+		return RatingMedium
+	case score < 18:
+		return RatingHard
+	default:
+		return RatingExpert
+	}
+}
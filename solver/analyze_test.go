@@ -0,0 +1,117 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name          string
+		start         []int
+		rows          int
+		cols          int
+		wantMoves     int
+		wantSolutions int
+		wantErr       error
+	}{
+		{"already solved", []int{1, 2, 3, 4}, 2, 2, 0, 1, nil},
+		{"2x2 one move", []int{1, 2, 4, 3}, 2, 2, 1, 1, nil},
+		{"3x3 single path", []int{1, 2, 3, 4, 9, 5, 7, 8, 6}, 3, 3, 2, 1, nil},
+		// Counts cross-checked with a breadth-first search.
+		{"3x3 two paths", []int{1, 2, 3, 4, 9, 8, 7, 6, 5}, 3, 3, 6, 2, nil},
+		{"3x3 two longer paths", []int{9, 1, 3, 4, 2, 8, 7, 6, 5}, 3, 3, 8, 2, nil},
+		{"unsolvable", []int{2, 1, 3, 4}, 2, 2, 0, 0, ErrUnsolvable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := Analyze(tt.start, StandardGoal(tt.rows, tt.cols), tt.rows, tt.cols)
+			if err != tt.wantErr {
+				t.Fatalf("Analyze() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if a.Moves != tt.wantMoves {
+				t.Errorf("Analyze().Moves = %d, want %d", a.Moves, tt.wantMoves)
+			}
+			if a.OptimalSolutions != tt.wantSolutions {
+				t.Errorf("Analyze().OptimalSolutions = %d, want %d", a.OptimalSolutions, tt.wantSolutions)
+			}
+			if a.HeuristicGap != a.Moves-a.RootHeuristic || a.HeuristicGap < 0 {
+				t.Errorf("Analyze().HeuristicGap = %d, moves %d, root heuristic %d", a.HeuristicGap, a.Moves, a.RootHeuristic)
+			}
+			if a.Rating == "" {
+				t.Error("Analyze().Rating should be set")
+			}
+		})
+	}
+}
+
+func TestAnalyzeContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := []int{16, 12, 9, 13, 15, 11, 10, 14, 3, 7, 2, 5, 4, 8, 6, 1}
+	if _, err := AnalyzeContext(ctx, start, StandardGoal(4, 4), 4, 4); !errors.Is(err, context.Canceled) {
+		t.Errorf("AnalyzeContext() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestNearGoalBranchingFactor(t *testing.T) {
+	tests := []struct {
+		name  string
+		plies []int
+		want  float64
+	}{
+		{"solved", []int{1}, 0},
+		{"one move", []int{1, 1}, 1},
+		// Only the last 3 plies count: (4 + 6 + 2) / (3 + 4 + 6).
+		{"long", []int{1, 3, 3, 4, 6, 2}, 12.0 / 13},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nearGoalBranchingFactor(tt.plies); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("nearGoalBranchingFactor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEffectiveBranchingFactor(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes int
+		depth int
+		want  float64
+	}{
+		{"no search", 0, 0, 0},
+		{"chain", 3, 3, 1},
+		{"binary", 14, 3, 2}, // 2 + 4 + 8
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := effectiveBranchingFactor(tt.nodes, tt.depth); math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("effectiveBranchingFactor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRate(t *testing.T) {
+	tests := []struct {
+		score float64
+		want  Rating
+	}{
+		{0, RatingEasy},
+		{8, RatingMedium},
+		{15, RatingHard},
+		{30, RatingExpert},
+	}
+	for _, tt := range tests {
+		if got := rate(tt.score); got != tt.want {
+			t.Errorf("rate(%v) = %v, want %v", tt.score, got, tt.want)
+		}
+	}
+}
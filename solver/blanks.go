@@ -0,0 +1,135 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidBlanks = errors.New("invalid number of blanks")
+
+// TileMove is a tile sliding from one cell into the neighboring blank.
+type TileMove struct {
+	Tile int `json:"tile"`
+	From int `json:"from"` // cell the tile leaves, which becomes blank
+	To   int `json:"to"`   // blank cell the tile enters
+}
+
+// MultiBlankSolvable reports whether start can reach goal on a rows x cols board with the
+// given number of blanks. The blanks are the values greater than rows*cols-blanks, so
+// boards are still permutations of 1 to rows*cols, and which blank ends up where does not
+// matter.
+//
+// With a single blank this is the parity rule of CheckSolvability. Two or more blanks make
+// every arrangement reachable: the tiles can be cycled around any 2x2 square through a
+// blank, and swapping the two indistinguishable blanks fixes the parity.
+//
+// Example:
+//
+//	// 7 and 8 are blanks; swapping tiles 1 and 2 cannot be done with one blank, but can with two.
+//	MultiBlankSolvable([]int{2, 1, 3, 4, 5, 6, 7, 8, 9}, StandardGoal(3, 3), 3, 3, 2) // true
+func MultiBlankSolvable(start, goal []int, rows, cols, blanks int) (bool, error) {
+	if err := validateBlanks(start, goal, rows, cols, blanks); err != nil {
+		return false, err
+	}
+	if blanks == 1 {
+		return isSolvable(start, goal, rows, cols), nil
+	}
+	return true, nil
+}
+
+// SolveMultiBlank finds a shortest solution of a puzzle with the given number of blanks,
+// the values greater than rows*cols-blanks, and returns it as tile moves. Blanks are
+// interchangeable: the solution ends with the goal's tiles in place and blanks in the
+// remaining cells. Every blank may move on each turn, but a blank never trades places with
+// another.
+//
+// opts.Stats, opts.Progress and opts.Wrap work as for SolveWithOptions. With a single blank,
+// the puzzle is solved by SolveWithOptions, tablebases and opts.Cache included; with more,
+// opts.Locked is not supported and the MultiTile metric returns ErrUnsupportedOption.
+//
+// Example:
+//
+//	// The blanks 5 and 6 start in the left column and end in the bottom right.
+//	moves, err := SolveMultiBlank(ctx, []int{5, 1, 2, 6, 3, 4}, StandardGoal(2, 3), 2, 3, 2, SolveOptions{})
+func SolveMultiBlank(ctx context.Context, start, goal []int, rows, cols, blanks int, opts SolveOptions) ([]TileMove, error) {
+	if err := validateBlanks(start, goal, rows, cols, blanks); err != nil {
+		return nil, err
+	}
+	if blanks == 1 {
+		path, err := SolveWithOptions(ctx, start, goal, rows, cols, opts)
+		if err != nil {
+			return nil, err
+		}
+		return tileMovesAlong(start, path), nil
+	}
+	if opts.Locked != nil {
+		return nil, fmt.Errorf("%w: locked cells need a single blank", ErrInvalidBlanks)
+	}
+	if opts.Metric != SingleTile {
+		return nil, fmt.Errorf("%w: several blanks in the %v metric", ErrUnsupportedOption, opts.Metric)
+	}
+
+	board, target := mergeBlanks(start, blanks), mergeBlanks(goal, blanks)
+	s := &searcher{ctx: ctx, progress: opts.Progress, goal: target, rows: rows, cols: cols}
+	if opts.Wrap != NoWrap {
+		t := newGraphGoal(target, wrappedGrid(rows, cols, opts.Wrap, nil))
+		t.rows, t.cols, t.wrap = rows, cols, opts.Wrap
+		s.target = t
+	}
+	root := newNode(board, rows, cols)
+	root.wrap = opts.Wrap
+	found, err := s.run(root)
+	if opts.Stats != nil {
+		*opts.Stats = Stats{NodesExpanded: s.expanded, Iterations: s.iterations}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return found.tileMoves(), nil
+}
+
+// validateBlanks checks start and goal and the number of blanks, which must leave at least
+// one tile.
+func validateBlanks(start, goal []int, rows, cols, blanks int) error {
+	if err := validate(start, rows, cols); err != nil {
+		return err
+	}
+	if err := validate(goal, rows, cols); err != nil {
+		return err
+	}
+	if blanks < 1 || blanks >= len(start) {
+		return fmt.Errorf("%w: %d blanks on a board of %d cells", ErrInvalidBlanks, blanks, len(start))
+	}
+	return nil
+}
+
+// mergeBlanks returns board with every blank, the values greater than len(board)-blanks,
+// replaced by len(board), the value the search treats as the blank.
+//
+// Example:
+//
+//	mergeBlanks([]int{5, 1, 2, 6, 3, 4}, 2) // returns []int{6, 1, 2, 6, 3, 4}
+func mergeBlanks(board []int, blanks int) []int {
+	merged := make([]int, len(board))
+	for i, v := range board {
+		if v > len(board)-blanks {
+			v = len(board)
+		}
+		merged[i] = v
+	}
+	return merged
+}
+
+// tileMovesAlong converts a path of blank indices, as returned by Solve, into tile moves.
+func tileMovesAlong(start, path []int) []TileMove {
+	board := make([]int, len(start))
+	copy(board, start)
+	moves := make([]TileMove, 0, len(path)-1)
+	for i := 1; i < len(path); i++ {
+		from, to := path[i], path[i-1]
+		moves = append(moves, TileMove{Tile: board[from], From: from, To: to})
+		board[from], board[to] = board[to], board[from]
+	}
+	return moves
+}
@@ -0,0 +1,172 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// multiBlankDistance searches breadth-first from the merged board start, each blank moving
+// on a grid, and returns the distance to goal, or -1, and the number of boards seen.
+func multiBlankDistance(start, goal []int, rows, cols int, wrap Wrap) (int, int) {
+	g := wrappedGrid(rows, cols, wrap, nil)
+	blank := len(start)
+	seen := map[string]bool{boardKey(start): true}
+	frontier := [][]int{start}
+	for d := 0; len(frontier) > 0; d++ {
+		var next [][]int
+		for _, b := range frontier {
+			if slices.Equal(b, goal) {
+				return d, len(seen)
+			}
+			for i, v := range b {
+				if v != blank {
+					continue
+				}
+				for _, w := range g.adj[i] {
+					if b[w] == blank {
+						continue
+					}
+					child := slices.Clone(b)
+					child[i], child[w] = child[w], child[i]
+					if key := boardKey(child); !seen[key] {
+						seen[key] = true
+						next = append(next, child)
+					}
+				}
+			}
+		}
+		frontier = next
+	}
+	return -1, len(seen)
+}
+
+func TestMultiBlank_Exhaustive(t *testing.T) {
+	tests := []struct {
+		name      string
+		rows      int
+		cols      int
+		blanks    int
+		reachable int
+	}{
+		// Two or more blanks reach every arrangement: n! divided by the orders of the blanks.
+		{"2x2 with 2 blanks", 2, 2, 2, 12},
+		{"2x3 with 2 blanks", 2, 3, 2, 360},
+		{"3x2 with 3 blanks", 3, 2, 3, 120},
+		{"3x3 with 3 blanks", 3, 3, 3, 60480},
+		{"2x3 with 1 blank", 2, 3, 1, 360},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goal := mergeBlanks(StandardGoal(tt.rows, tt.cols), tt.blanks)
+			if _, got := multiBlankDistance(goal, nil, tt.rows, tt.cols, NoWrap); got != tt.reachable {
+				t.Errorf("search reaches %d boards, want %d", got, tt.reachable)
+			}
+		})
+	}
+}
+
+func TestMultiBlankSolvable(t *testing.T) {
+	swapped := []int{2, 1, 3, 4, 5, 6, 7, 8, 9}
+	tests := []struct {
+		name    string
+		start   []int
+		blanks  int
+		want    bool
+		wantErr error
+	}{
+		{"swap with 1 blank", swapped, 1, false, nil},
+		{"swap with 2 blanks", swapped, 2, true, nil},
+		{"blanks in any order", []int{1, 2, 3, 4, 5, 6, 9, 8, 7}, 3, true, nil},
+		{"no blanks", swapped, 0, false, ErrInvalidBlanks},
+		{"no tiles", swapped, 9, false, ErrInvalidBlanks},
+		{"invalid board", []int{1, 2, 3}, 2, false, ErrSizeMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MultiBlankSolvable(tt.start, StandardGoal(3, 3), 3, 3, tt.blanks)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("MultiBlankSolvable() = %v, %v; want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSolveMultiBlank(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   []int
+		rows    int
+		cols    int
+		blanks  int
+		wrap    Wrap
+		locked  []bool
+		wantErr error
+	}{
+		{"2x3 with 2 blanks", []int{5, 1, 2, 6, 3, 4}, 2, 3, 2, NoWrap, nil, nil},
+		{"3x3 swap with 2 blanks", []int{2, 1, 3, 4, 5, 6, 7, 8, 9}, 3, 3, 2, NoWrap, nil, nil},
+		{"3x3 with 3 blanks", []int{7, 5, 1, 9, 3, 8, 6, 2, 4}, 3, 3, 3, NoWrap, nil, nil},
+		{"3x4 with 2 blanks", []int{5, 1, 3, 4, 2, 11, 7, 8, 9, 6, 10, 12}, 3, 4, 2, NoWrap, nil, nil},
+		{"3x3 torus with 2 blanks", []int{8, 6, 7, 2, 5, 4, 3, 9, 1}, 3, 3, 2, Torus, nil, nil},
+		{"single blank", []int{1, 2, 3, 4, 5, 9, 7, 8, 6}, 3, 3, 1, NoWrap, nil, nil},
+		{"single blank swap", []int{2, 1, 3, 4, 5, 6, 7, 8, 9}, 3, 3, 1, NoWrap, nil, ErrUnsolvable},
+		{"locked with 2 blanks", []int{2, 1, 3, 4, 5, 6, 7, 8, 9}, 3, 3, 2, NoWrap, lockedMask(9, 0), ErrInvalidBlanks},
+		{"too many blanks", []int{1, 2, 3, 4}, 2, 2, 4, NoWrap, nil, ErrInvalidBlanks},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goal := StandardGoal(tt.rows, tt.cols)
+			moves, err := SolveMultiBlank(context.Background(), tt.start, goal, tt.rows, tt.cols, tt.blanks, SolveOptions{Wrap: tt.wrap, Locked: tt.locked})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SolveMultiBlank() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			g := wrappedGrid(tt.rows, tt.cols, tt.wrap, nil)
+			board := slices.Clone(tt.start)
+			isBlank := func(v int) bool { return v > len(board)-tt.blanks }
+			for _, m := range moves {
+				if board[m.From] != m.Tile || isBlank(m.Tile) || !isBlank(board[m.To]) || !slices.Contains(g.adj[m.From], m.To) {
+					t.Fatalf("move %+v cannot be played on %v", m, board)
+				}
+				board[m.From], board[m.To] = board[m.To], board[m.From]
+			}
+			if got, want := mergeBlanks(board, tt.blanks), mergeBlanks(goal, tt.blanks); !slices.Equal(got, want) {
+				t.Fatalf("moves %+v end at %v", moves, board)
+			}
+			if want, _ := multiBlankDistance(mergeBlanks(tt.start, tt.blanks), mergeBlanks(goal, tt.blanks), tt.rows, tt.cols, tt.wrap); len(moves) != want {
+				t.Errorf("solution takes %d moves, want %d", len(moves), want)
+			}
+		})
+	}
+}
+
+func TestSolveMultiBlank_Metric(t *testing.T) {
+	goal := StandardGoal(3, 3)
+	// A single blank is solved by SolveWithOptions, which counts moves in either metric.
+	moves, err := SolveMultiBlank(context.Background(), []int{1, 2, 3, 4, 5, 6, 9, 7, 8}, goal, 3, 3, 1, SolveOptions{Metric: MultiTile})
+	if err != nil || len(moves) != 2 {
+		t.Errorf("SolveMultiBlank() with one blank = %+v, %v; want 2 tile moves", moves, err)
+	}
+	if _, err := SolveMultiBlank(context.Background(), []int{5, 1, 2, 6, 3, 4}, StandardGoal(2, 3), 2, 3, 2, SolveOptions{Metric: MultiTile}); !errors.Is(err, ErrUnsupportedOption) {
+		t.Errorf("SolveMultiBlank() with two blanks error = %v, want %v", err, ErrUnsupportedOption)
+	}
+}
+
+func TestMergeBlanks(t *testing.T) {
+	if got, want := mergeBlanks([]int{5, 1, 2, 6, 3, 4}, 2), []int{6, 1, 2, 6, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("mergeBlanks() = %v, want %v", got, want)
+	}
+}
+
+func TestTileMovesAlong(t *testing.T) {
+	// The blank at 5 moves up to 2, then left to 1.
+	got := tileMovesAlong([]int{1, 2, 3, 4, 5, 6}, []int{5, 2, 1})
+	want := []TileMove{{Tile: 3, From: 2, To: 5}, {Tile: 2, From: 1, To: 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tileMovesAlong() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,240 @@
+package solver
+
+import (
+	"bufio"
+	"container/list"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"sync"
+)
+
+// Cache remembers solutions so that boards solved before are answered without searching.
+// It keeps the most recently used solutions in memory and, when opened with OpenCache,
+// every solution in an append-only file. Solutions are stored for the canonical board
+// (see Canonicalize), so a board symmetric to one solved before is a hit as well.
+// A Cache is safe for concurrent use.
+//
+// Example:
+//
+//	cache := NewCache(10000)
+//	path, err := SolveWithOptions(ctx, start, goal, 3, 3, SolveOptions{Cache: cache})
+type Cache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element // in memory, values are *cacheEntry
+	recent   *list.List               // front is the most recently used
+	file     *os.File                 // optional
+	offsets  map[string]int64         // where each solution starts in file
+	stats    CacheStats
+}
+
+// CacheStats reports how well a Cache works.
+type CacheStats struct {
+	Hits int `json:"hits"`
+	// DiskHits is the number of hits found in the file but not in memory.
+	DiskHits int `json:"disk_hits"`
+	Misses   int `json:"misses"`
+	// Entries is the number of solutions held in memory.
+	Entries int `json:"entries"`
+	// Stored is the number of solutions in the file.
+	Stored int `json:"stored"`
+}
+
+type cacheEntry struct {
+	key  string
+	path []int // solves the canonical board
+}
+
+// cacheRecord is one line of a cache file.
+type cacheRecord struct {
+	Rows  int   `json:"rows"`
+	Cols  int   `json:"cols"`
+	Start []int `json:"start"`
+	Goal  []int `json:"goal"`
+	Path  []int `json:"path"`
+}
+
+// NewCache returns an in-memory cache holding up to capacity solutions.
+// The least recently used solution is dropped when it is full.
+func NewCache(capacity int) *Cache {
+	return &Cache{
+		capacity: max(capacity, 1),
+		entries:  make(map[string]*list.Element),
+		recent:   list.New(),
+	}
+}
+
+// OpenCache returns a cache backed by the file at name, which is created if needed.
+// The file holds one JSON solution per line and only ever grows; capacity bounds
+// the solutions held in memory, not in the file. A truncated last line, as left by
+// a crash, is discarded. Close the cache to release the file.
+//
+// Example:
+//
+//	cache, err := OpenCache("solutions.jsonl", 10000)
+//	if err != nil {
+//		return err
+//	}
+//	defer cache.Close()
+func OpenCache(name string, capacity int) (*Cache, error) {
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	c := NewCache(capacity)
+	c.file = f
+	c.offsets = make(map[string]int64)
+	if err := c.index(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// index records the offset of every complete line of the cache file.
+func (c *Cache) index() error {
+	r := bufio.NewReader(c.file)
+	var offset int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				// Drop the partial line so that the next append starts on a line of its own.
+				return c.file.Truncate(offset)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var rec cacheRecord
+		if json.Unmarshal(line, &rec) == nil && rec.valid() {
+			c.offsets[cacheKey(rec.Start, rec.Goal, rec.Rows, rec.Cols)] = offset
+		}
+		offset += int64(len(line))
+	}
+}
+
+// Close releases the cache file, if any.
+func (c *Cache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.file = nil
+	return err
+}
+
+// Stats returns the statistics gathered since the cache was created.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.stats
+	s.Entries = c.recent.Len()
+	s.Stored = len(c.offsets)
+	return s
+}
+
+// get returns the cached solution for the puzzle.
+func (c *Cache) get(start, goal []int, rows, cols int) ([]int, bool) {
+	canonical, sym := canonicalize(start, goalSymmetries(goal, rows, cols))
+	key := cacheKey(canonical, goal, rows, cols)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.recent.MoveToFront(e)
+		c.stats.Hits++
+		return sym.Inverse().MapPath(e.Value.(*cacheEntry).path), true
+	}
+	if path, ok := c.load(key); ok {
+		c.remember(key, path)
+		c.stats.Hits++
+		c.stats.DiskHits++
+		return sym.Inverse().MapPath(path), true
+	}
+	c.stats.Misses++
+	return nil, false
+}
+
+// put stores the solution of the puzzle in memory and, when not there yet, in the file.
+func (c *Cache) put(start, goal []int, rows, cols int, path []int) error {
+	canonical, sym := canonicalize(start, goalSymmetries(goal, rows, cols))
+	key := cacheKey(canonical, goal, rows, cols)
+	path = sym.MapPath(path)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remember(key, path)
+	if c.file == nil {
+		return nil
+	}
+	if _, ok := c.offsets[key]; ok {
+		return nil
+	}
+
+	line, err := json.Marshal(cacheRecord{Rows: rows, Cols: cols, Start: canonical, Goal: goal, Path: path})
+	if err != nil {
+		return err
+	}
+	offset, err := c.file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if _, err := c.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	c.offsets[key] = offset
+	return nil
+}
+
+// remember puts a solution at the front of the in-memory list, evicting the least
+// recently used one when the cache is full.
+func (c *Cache) remember(key string, path []int) {
+	if e, ok := c.entries[key]; ok {
+		c.recent.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.recent.PushFront(&cacheEntry{key: key, path: path})
+	if c.recent.Len() > c.capacity {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// load reads the solution of the canonical board stored under key from the file.
+func (c *Cache) load(key string) ([]int, bool) {
+	offset, ok := c.offsets[key]
+	if !ok || c.file == nil {
+		return nil, false
+	}
+	line, err := bufio.NewReader(io.NewSectionReader(c.file, offset, 1<<62)).ReadBytes('\n')
+	if err != nil {
+		return nil, false
+	}
+	var rec cacheRecord
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return nil, false
+	}
+	return rec.Path, true
+}
+
+// valid reports whether the record holds valid boards with a canonical start, as put
+// writes them. Other lines are ignored.
+func (rec cacheRecord) valid() bool {
+	if validate(rec.Start, rec.Rows, rec.Cols) != nil || validate(rec.Goal, rec.Rows, rec.Cols) != nil {
+		return false
+	}
+	canonical, _ := canonicalize(rec.Start, goalSymmetries(rec.Goal, rec.Rows, rec.Cols))
+	return slices.Equal(canonical, rec.Start)
+}
+
+// cacheKey encodes a puzzle as its dimensions followed by both boards.
+// The start board is expected to be canonical.
+func cacheKey(start, goal []int, rows, cols int) string {
+	return boardKey([]int{rows, cols}) + boardKey(start) + boardKey(goal)
+}
@@ -0,0 +1,159 @@
+package solver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCache_SolveWithOptions(t *testing.T) {
+	cache := NewCache(10)
+	start, goal := []int{1, 8, 2, 4, 3, 5, 7, 6, 9}, StandardGoal(3, 3)
+
+	var stats Stats
+	first, err := SolveWithOptions(context.Background(), start, goal, 3, 3, SolveOptions{Cache: cache, Stats: &stats})
+	if err != nil {
+		t.Fatalf("SolveWithOptions() error = %v", err)
+	}
+	if stats.CacheHit {
+		t.Error("first solve should not hit the cache")
+	}
+
+	second, err := SolveWithOptions(context.Background(), start, goal, 3, 3, SolveOptions{Cache: cache, Stats: &stats})
+	if err != nil {
+		t.Fatalf("SolveWithOptions() error = %v", err)
+	}
+	if !stats.CacheHit || stats.NodesExpanded != 0 {
+		t.Errorf("second solve stats = %+v, want a cache hit without search", stats)
+	}
+	if !slices.Equal(first, second) {
+		t.Errorf("cached path = %v, want %v", second, first)
+	}
+
+	second[0] = -1
+	if path, _ := cache.get(start, goal, 3, 3); path[0] == -1 {
+		t.Error("modifying a returned path changed the cached solution")
+	}
+	if got, want := cache.Stats(), (CacheStats{Hits: 2, Misses: 1, Entries: 1}); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCache_Eviction(t *testing.T) {
+	// A 2x3 goal has no symmetries, so that every board has an entry of its own.
+	cache := NewCache(2)
+	goal := StandardGoal(2, 3)
+	a, b, c := []int{1, 2, 3, 4, 6, 5}, []int{1, 2, 3, 6, 4, 5}, []int{1, 2, 6, 4, 5, 3}
+	cache.put(a, goal, 2, 3, []int{4, 5})
+	cache.put(b, goal, 2, 3, []int{3, 4, 5})
+	cache.get(a, goal, 2, 3) // b is now the least recently used
+	cache.put(c, goal, 2, 3, []int{2, 5})
+
+	if _, ok := cache.get(b, goal, 2, 3); ok {
+		t.Error("least recently used entry should have been evicted")
+	}
+	for _, board := range [][]int{a, c} {
+		if _, ok := cache.get(board, goal, 2, 3); !ok {
+			t.Errorf("entry %v should still be cached", board)
+		}
+	}
+}
+
+func TestCache_Key(t *testing.T) {
+	// The same values read as a 2x3 and a 3x2 board are different puzzles.
+	board := []int{1, 2, 3, 4, 5, 6}
+	if cacheKey(board, board, 2, 3) == cacheKey(board, board, 3, 2) {
+		t.Error("cacheKey() should depend on the dimensions")
+	}
+}
+
+func TestOpenCache(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "cache.jsonl")
+	start, goal := []int{1, 2, 3, 4, 6, 5}, StandardGoal(2, 3)
+
+	cache, err := OpenCache(name, 1)
+	if err != nil {
+		t.Fatalf("OpenCache() error = %v", err)
+	}
+	if err := cache.put(start, goal, 2, 3, []int{4, 5}); err != nil {
+		t.Fatalf("put() error = %v", err)
+	}
+	cache.Close()
+
+	// Simulate a crash in the middle of an append.
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"rows":2,"cols":3,"sta`)
+	f.Close()
+
+	cache, err = OpenCache(name, 1)
+	if err != nil {
+		t.Fatalf("OpenCache() error = %v", err)
+	}
+	defer cache.Close()
+	// Evict the entry from memory so that it has to be read from the file.
+	cache.put([]int{1, 2, 3, 6, 4, 5}, goal, 2, 3, []int{3, 4, 5})
+
+	path, ok := cache.get(start, goal, 2, 3)
+	if !ok || !slices.Equal(path, []int{4, 5}) {
+		t.Errorf("get() = %v, %v, want [4 5], true", path, ok)
+	}
+	if got, want := cache.Stats(), (CacheStats{Hits: 1, DiskHits: 1, Entries: 1, Stored: 2}); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCache_Symmetric(t *testing.T) {
+	cache := NewCache(10)
+	goal := StandardGoal(3, 3)
+	// Mirror images across the main diagonal.
+	board := []int{1, 2, 3, 4, 5, 6, 9, 7, 8}
+	mirrored := []int{1, 2, 9, 4, 5, 3, 7, 8, 6}
+	if _, err := SolveWithOptions(context.Background(), board, goal, 3, 3, SolveOptions{Cache: cache}); err != nil {
+		t.Fatalf("SolveWithOptions() error = %v", err)
+	}
+
+	path, ok := cache.get(mirrored, goal, 3, 3)
+	if !ok {
+		t.Fatal("the mirror image should hit the cache")
+	}
+	if want := []int{2, 5, 8}; !slices.Equal(path, want) {
+		t.Errorf("get() = %v, want %v", path, want)
+	}
+}
+
+func TestOpenCache_NonCanonical(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "cache.jsonl")
+	// Mirror images across the main diagonal, only one of which is canonical, and an
+	// invalid board.
+	lines := `{"rows":3,"cols":3,"start":[1,2,3,4,5,6,9,7,8],"goal":[1,2,3,4,5,6,7,8,9],"path":[6,7,8]}
+{"rows":3,"cols":3,"start":[1,2,9,4,5,3,7,8,6],"goal":[1,2,3,4,5,6,7,8,9],"path":[2,5,8]}
+{"rows":3,"cols":3,"start":[1,1,9,4,5,3,7,8,6],"goal":[1,2,3,4,5,6,7,8,9],"path":[2,5,8]}
+`
+	if err := os.WriteFile(name, []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cache, err := OpenCache(name, 1)
+	if err != nil {
+		t.Fatalf("OpenCache() error = %v", err)
+	}
+	defer cache.Close()
+	if got := cache.Stats().Stored; got != 1 {
+		t.Errorf("Stats().Stored = %d, want 1", got)
+	}
+	goal := StandardGoal(3, 3)
+	for _, tt := range []struct {
+		start, want []int
+	}{
+		{[]int{1, 2, 3, 4, 5, 6, 9, 7, 8}, []int{6, 7, 8}},
+		{[]int{1, 2, 9, 4, 5, 3, 7, 8, 6}, []int{2, 5, 8}},
+	} {
+		if path, ok := cache.get(tt.start, goal, 3, 3); !ok || !slices.Equal(path, tt.want) {
+			t.Errorf("get(%v) = %v, %v, want %v, true", tt.start, path, ok, tt.want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package solver
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrExploreTooLarge = errors.New("board too large to explore")
+
+// MaxExploreCells is the largest board, in cells, that Explore searches in memory.
+// The search keeps two bits per solvable board: 60 MB for 12 cells.
+const MaxExploreCells = 12
+
+// DefaultMaxAntipodes is the number of antipodes Explore lists when ExploreOptions.MaxAntipodes is zero.
+const DefaultMaxAntipodes = 100
+
+// ExploreOptions configures Explore.
+type ExploreOptions struct {
+	Rows int
+	Cols int
+	// Goal is the configuration distances are measured to.
+	// StandardGoal(Rows, Cols) is used when Goal is nil.
+	Goal []int
+	// MaxAntipodes bounds the number of antipodes listed; DefaultMaxAntipodes is used
+	// when it is zero and none are listed when it is negative.
+	MaxAntipodes int
+	// Dir, when set, makes Explore keep its layers in files in this directory instead of
+	// in memory, which lifts the limit to MaxFrontierCells. A search interrupted by ctx
+	// or a crash resumes from its last completed layer when run again with the same Dir.
+	Dir string
+	// RunSize is the number of ranks sorted in memory at once when Dir is set;
+	// DefaultRunSize is used when it is zero.
+	RunSize int
+	// Progress, when set, is called after each layer is completed with its distance
+	// and its number of boards.
+	Progress func(distance, count int)
+}
+
+// Exploration describes the state space of a puzzle as seen from its goal.
+type Exploration struct {
+	Rows int   `json:"rows"`
+	Cols int   `json:"cols"`
+	Goal []int `json:"goal"`
+	// Counts holds the number of boards at each distance from the goal.
+	Counts []int `json:"counts"`
+	// States is the number of boards that can reach the goal.
+	States int `json:"states"`
+	// MaxDistance is the largest optimal distance, God's number for the goal.
+	MaxDistance int `json:"max_distance"`
+	// Antipodes lists boards at MaxDistance, up to ExploreOptions.MaxAntipodes of them.
+	Antipodes [][]int `json:"antipodes"`
+}
+
+// Explore runs a breadth-first search from the goal over every solvable board and
+// reports how many boards lie at each distance. Boards of up to MaxExploreCells cells
+// are searched in memory, larger ones only with ExploreOptions.Dir; ctx can stop the
+// search, which takes minutes for 12 cells.
+//
+// Example:
+//
+//	e, err := Explore(ctx, ExploreOptions{Rows: 3, Cols: 3})
+//	fmt.Println(e.MaxDistance, len(e.Antipodes)) // 31 2
+func Explore(ctx context.Context, opts ExploreOptions) (Exploration, error) {
+	goal := opts.Goal
+	if goal == nil {
+		goal = StandardGoal(opts.Rows, opts.Cols)
+	}
+	if err := validate(goal, opts.Rows, opts.Cols); err != nil {
+		return Exploration{}, err
+	}
+	maxAntipodes := opts.MaxAntipodes
+	if maxAntipodes == 0 {
+		maxAntipodes = DefaultMaxAntipodes
+	}
+	if opts.Dir != "" {
+		return exploreOnDisk(ctx, opts, goal, maxAntipodes)
+	}
+	if len(goal) > MaxExploreCells {
+		return Exploration{}, ErrExploreTooLarge
+	}
+
+	e := Exploration{Rows: opts.Rows, Cols: opts.Cols, Goal: goal}
+	ranker := newSolvableRanker(goal, opts.Rows, opts.Cols)
+	codes := newLayerCodes(solvableStates(len(goal)))
+	codes.set(ranker.rank(goal), codeFrontier)
+
+	board := make([]int, len(goal))
+	expanded := 0
+	for count := 1; count > 0; {
+		e.Counts = append(e.Counts, count)
+		e.States += count
+		count = 0
+		var err error
+		codes.each(codeFrontier, func(rank int) bool {
+			ranker.unrank(rank, board)
+			n := newNode(board, opts.Rows, opts.Cols)
+			for _, dir := range n.directions() {
+				child := n.copy()
+				child.moveBlank(dir)
+				if r := ranker.rank(child.board); codes.get(r) == codeUnseen {
+					codes.set(r, codeNext)
+					count++
+				}
+			}
+			if expanded++; expanded%cancelCheckInterval == 0 {
+				err = ctx.Err()
+			}
+			return err == nil
+		})
+		if err != nil {
+			return Exploration{}, err
+		}
+		if count > 0 {
+			codes.advance()
+			if opts.Progress != nil {
+				opts.Progress(len(e.Counts), count)
+			}
+		}
+	}
+	e.MaxDistance = len(e.Counts) - 1
+
+	if maxAntipodes > 0 {
+		codes.each(codeFrontier, func(rank int) bool {
+			antipode := make([]int, len(goal))
+			ranker.unrank(rank, antipode)
+			e.Antipodes = append(e.Antipodes, antipode)
+			return len(e.Antipodes) < maxAntipodes
+		})
+	}
+	return e, nil
+}
+
+// Codes of boards during a layered breadth-first search.
+const (
+	codeUnseen   = 0
+	codeFrontier = 1 // in the layer being expanded
+	codeNext     = 2 // in the layer being generated
+	codeClosed   = 3 // in an earlier layer
+)
+
+// layerCodes holds a two-bit code per board rank, four to a byte.
+type layerCodes []byte
+
+func newLayerCodes(states int) layerCodes {
+	return make(layerCodes, (states+3)/4)
+}
+
+func (c layerCodes) get(rank int) byte {
+	return c[rank>>2] >> (rank & 3 * 2) & 3
+}
+
+func (c layerCodes) set(rank int, code byte) {
+	shift := rank & 3 * 2
+	c[rank>>2] = c[rank>>2]&^(3<<shift) | code<<shift
+}
+
+// each calls fn with every rank holding code, in increasing order, until fn returns false.
+func (c layerCodes) each(code byte, fn func(rank int) bool) {
+	for i, b := range c {
+		if b == 0 {
+			continue
+		}
+		for slot := range 4 {
+			if b>>(slot*2)&3 == code && !fn(i*4+slot) {
+				return
+			}
+		}
+	}
+}
+
+// advance closes the frontier and makes the next layer the frontier:
+// codeFrontier becomes codeClosed and codeNext becomes codeFrontier.
+func (c layerCodes) advance() {
+	for i, b := range c {
+		lo, hi := b&0x55, b>>1&0x55
+		c[i] = lo<<1 | lo | hi
+	}
+}
@@ -0,0 +1,92 @@
+package solver
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestExplore(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          ExploreOptions
+		wantStates    int
+		wantMax       int
+		wantAntipodes int
+	}{
+		{"2x2", ExploreOptions{Rows: 2, Cols: 2}, 12, 6, 1},
+		{"2x3", ExploreOptions{Rows: 2, Cols: 3}, 360, 21, 1},
+		{"3x3", ExploreOptions{Rows: 3, Cols: 3}, 181440, 31, 2},
+		{"3x3 limited antipodes", ExploreOptions{Rows: 3, Cols: 3, MaxAntipodes: 1}, 181440, 31, 1},
+		{"3x3 blank in the center", ExploreOptions{Rows: 3, Cols: 3, Goal: []int{1, 2, 3, 4, 9, 5, 6, 7, 8}, MaxAntipodes: 200}, 181440, 30, 148},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Explore(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatalf("Explore() error = %v", err)
+			}
+			if e.States != tt.wantStates || e.MaxDistance != tt.wantMax || len(e.Antipodes) != tt.wantAntipodes {
+				t.Errorf("Explore() = %d states, max distance %d, %d antipodes; want %d, %d, %d",
+					e.States, e.MaxDistance, len(e.Antipodes), tt.wantStates, tt.wantMax, tt.wantAntipodes)
+			}
+			sum := 0
+			for _, c := range e.Counts {
+				sum += c
+			}
+			if sum != e.States || len(e.Counts) != e.MaxDistance+1 {
+				t.Errorf("Counts = %v do not add up to %d states at distances 0..%d", e.Counts, e.States, e.MaxDistance)
+			}
+			if len(e.Antipodes) > 0 {
+				path, err := Solve(e.Antipodes[0], e.Goal, tt.opts.Rows, tt.opts.Cols)
+				if err != nil || len(path)-1 != e.MaxDistance {
+					t.Errorf("antipode %v solves in %d moves, %v; want %d", e.Antipodes[0], len(path)-1, err, e.MaxDistance)
+				}
+			}
+		})
+	}
+}
+
+func TestExplore_Counts(t *testing.T) {
+	// Every 2x2 board other than the goal and the antipode has a twin in the other direction.
+	e, err := Explore(context.Background(), ExploreOptions{Rows: 2, Cols: 2})
+	if err != nil {
+		t.Fatalf("Explore() error = %v", err)
+	}
+	if want := []int{1, 2, 2, 2, 2, 2, 1}; !slices.Equal(e.Counts, want) {
+		t.Errorf("Counts = %v, want %v", e.Counts, want)
+	}
+}
+
+func TestExplore_Errors(t *testing.T) {
+	if _, err := Explore(context.Background(), ExploreOptions{Rows: 4, Cols: 4}); err != ErrExploreTooLarge {
+		t.Errorf("Explore(4x4) error = %v, want %v", err, ErrExploreTooLarge)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Explore(ctx, ExploreOptions{Rows: 3, Cols: 3}); err != context.Canceled {
+		t.Errorf("Explore() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestLayerCodes(t *testing.T) {
+	codes := newLayerCodes(9)
+	codes.set(0, codeClosed)
+	codes.set(4, codeFrontier)
+	codes.set(5, codeNext)
+	codes.set(8, codeNext)
+
+	var next []int
+	codes.each(codeNext, func(rank int) bool { next = append(next, rank); return true })
+	if !slices.Equal(next, []int{5, 8}) {
+		t.Errorf("each(codeNext) = %v, want [5 8]", next)
+	}
+
+	codes.advance()
+	want := []byte{codeClosed, codeUnseen, codeUnseen, codeUnseen, codeClosed, codeFrontier, codeUnseen, codeUnseen, codeFrontier}
+	for rank, code := range want {
+		if got := codes.get(rank); got != code {
+			t.Errorf("after advance, get(%d) = %d, want %d", rank, got, code)
+		}
+	}
+}
@@ -0,0 +1,464 @@
+package solver
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// MaxFrontierCells is the largest board, in cells, that Explore searches on disk:
+// the ranks of larger boards do not fit in 64 bits.
+const MaxFrontierCells = 20
+
+// DefaultRunSize is the number of ranks sorted in memory at once by the disk-based
+// search when ExploreOptions.RunSize is zero (32 MB).
+const DefaultRunSize = 1 << 22
+
+// frontierVersion is the version of the on-disk layout written by exploreOnDisk.
+const frontierVersion = 1
+
+// frontierMeta is the progress of a disk-based search, kept in meta.json.
+type frontierMeta struct {
+	Version int   `json:"version"`
+	Rows    int   `json:"rows"`
+	Cols    int   `json:"cols"`
+	Goal    []int `json:"goal"`
+	// Counts holds the number of boards in each completed layer, which is the histogram so far.
+	Counts []int `json:"counts"`
+	// Complete is set once a layer turned out empty.
+	Complete bool `json:"complete"`
+}
+
+// frontierSearch is a breadth-first search that keeps its layers on disk.
+// The directory holds meta.json and the files of the last two layers, layer-NNNN.ranks,
+// each a sorted list of board ranks (see RankSolvable) stored as uvarint deltas.
+// A layer is generated from the previous one in runs that are sorted in memory and
+// merged into the next layer file, dropping duplicates and the boards of the layer
+// before, which are the only other neighbors in the bipartite puzzle graph.
+// Only completed layers are recorded in meta.json, so an interrupted search resumes
+// with the layer it was generating.
+type frontierSearch struct {
+	ctx     context.Context
+	dir     string
+	rows    int
+	cols    int
+	ranker  *solvableRanker
+	runSize int
+	meta    frontierMeta
+}
+
+// exploreOnDisk is Explore for ExploreOptions.Dir.
+func exploreOnDisk(ctx context.Context, opts ExploreOptions, goal []int, maxAntipodes int) (Exploration, error) {
+	if len(goal) > MaxFrontierCells {
+		return Exploration{}, ErrExploreTooLarge
+	}
+	s := &frontierSearch{
+		ctx:     ctx,
+		dir:     opts.Dir,
+		rows:    opts.Rows,
+		cols:    opts.Cols,
+		ranker:  newSolvableRanker(goal, opts.Rows, opts.Cols),
+		runSize: opts.RunSize,
+	}
+	if s.runSize <= 0 {
+		s.runSize = DefaultRunSize
+	}
+	if err := s.open(goal); err != nil {
+		return Exploration{}, err
+	}
+
+	for !s.meta.Complete {
+		if err := ctx.Err(); err != nil {
+			return Exploration{}, err
+		}
+		d := len(s.meta.Counts) - 1
+		count, err := s.expand(d)
+		if err != nil {
+			return Exploration{}, err
+		}
+		if count == 0 {
+			os.Remove(s.layerPath(d + 1))
+			s.meta.Complete = true
+		} else {
+			s.meta.Counts = append(s.meta.Counts, count)
+		}
+		if err := s.saveMeta(); err != nil {
+			return Exploration{}, err
+		}
+		if count > 0 && d > 0 {
+			os.Remove(s.layerPath(d - 1))
+		}
+		if opts.Progress != nil && count > 0 {
+			opts.Progress(d+1, count)
+		}
+	}
+
+	e := Exploration{Rows: opts.Rows, Cols: opts.Cols, Goal: goal, Counts: s.meta.Counts}
+	for _, c := range e.Counts {
+		e.States += c
+	}
+	e.MaxDistance = len(e.Counts) - 1
+	if maxAntipodes > 0 {
+		antipodes, err := s.boards(e.MaxDistance, maxAntipodes)
+		if err != nil {
+			return Exploration{}, err
+		}
+		e.Antipodes = antipodes
+	}
+	return e, nil
+}
+
+// open resumes the search recorded in the directory or starts a new one from goal.
+func (s *frontierSearch) open(goal []int) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	// Runs and layers still being written belong to an interrupted layer.
+	tmp, err := filepath.Glob(filepath.Join(s.dir, "*.tmp"))
+	if err != nil {
+		return err
+	}
+	for _, name := range tmp {
+		os.Remove(name)
+	}
+
+	data, err := os.ReadFile(s.metaPath())
+	if errors.Is(err, os.ErrNotExist) {
+		s.meta = frontierMeta{Version: frontierVersion, Rows: s.rows, Cols: s.cols, Goal: goal, Counts: []int{1}}
+		if err := s.writeLayer(0, []int{s.ranker.rank(goal)}); err != nil {
+			return err
+		}
+		return s.saveMeta()
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &s.meta); err != nil {
+		return fmt.Errorf("%s: %w", s.metaPath(), err)
+	}
+	if s.meta.Version != frontierVersion || s.meta.Rows != s.rows || s.meta.Cols != s.cols || !slices.Equal(s.meta.Goal, goal) {
+		return fmt.Errorf("%s holds a search for another puzzle", s.dir)
+	}
+	// A search interrupted before deleting its oldest layer leaves it behind.
+	if d := len(s.meta.Counts) - 3; d >= 0 {
+		os.Remove(s.layerPath(d))
+	}
+	return nil
+}
+
+// expand generates layer d+1 from layers d and d-1 and returns its size.
+func (s *frontierSearch) expand(d int) (int, error) {
+	var runs []string
+	defer func() {
+		for _, name := range runs {
+			os.Remove(name)
+		}
+	}()
+
+	buf := make([]int, 0, s.runSize)
+	flush := func() error {
+		if len(buf) == 0 {
+			return nil
+		}
+		slices.Sort(buf)
+		name := filepath.Join(s.dir, fmt.Sprintf("run-%04d-%04d.tmp", d+1, len(runs)))
+		runs = append(runs, name)
+		err := writeRanks(name, slices.Compact(buf))
+		buf = buf[:0]
+		return err
+	}
+
+	board := make([]int, s.rows*s.cols)
+	expanded := 0
+	err := s.eachRank(d, func(rank int) error {
+		s.ranker.unrank(rank, board)
+		n := newNode(board, s.rows, s.cols)
+		for _, dir := range n.directions() {
+			child := n.copy()
+			child.moveBlank(dir)
+			buf = append(buf, s.ranker.rank(child.board))
+		}
+		if len(buf)+4 > s.runSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if expanded++; expanded%cancelCheckInterval == 0 {
+			return s.ctx.Err()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	sources := make([]*rankReader, 0, len(runs))
+	for _, name := range runs {
+		r, err := openRanks(name)
+		if err != nil {
+			return 0, err
+		}
+		defer r.close()
+		sources = append(sources, r)
+	}
+	var previous *rankReader
+	if d > 0 {
+		if previous, err = openRanks(s.layerPath(d - 1)); err != nil {
+			return 0, err
+		}
+		defer previous.close()
+	}
+	return s.merge(d+1, sources, previous)
+}
+
+// merge writes the union of the sorted sources, without the ranks in previous, as layer d.
+func (s *frontierSearch) merge(d int, sources []*rankReader, previous *rankReader) (int, error) {
+	tmp := s.layerPath(d) + ".tmp"
+	w, err := createRanks(tmp)
+	if err != nil {
+		return 0, err
+	}
+	defer w.abort()
+
+	h := make(rankHeap, 0, len(sources))
+	for _, r := range sources {
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+		if !r.done {
+			h = append(h, r)
+		}
+	}
+	heap.Init(&h)
+	if previous != nil {
+		if err := previous.next(); err != nil {
+			return 0, err
+		}
+	}
+
+	last := -1
+	for len(h) > 0 {
+		r := h[0]
+		rank := r.rank
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+		if r.done {
+			heap.Pop(&h)
+		} else {
+			heap.Fix(&h, 0)
+		}
+		if rank == last {
+			continue
+		}
+		last = rank
+		for previous != nil && !previous.done && previous.rank < rank {
+			if err := previous.next(); err != nil {
+				return 0, err
+			}
+		}
+		if previous != nil && !previous.done && previous.rank == rank {
+			continue
+		}
+		if err := w.write(rank); err != nil {
+			return 0, err
+		}
+	}
+	if err := w.commit(s.layerPath(d)); err != nil {
+		return 0, err
+	}
+	return w.count, nil
+}
+
+// eachRank calls fn with every rank of layer d in increasing order.
+func (s *frontierSearch) eachRank(d int, fn func(rank int) error) error {
+	r, err := openRanks(s.layerPath(d))
+	if err != nil {
+		return err
+	}
+	defer r.close()
+	for {
+		if err := r.next(); err != nil {
+			return err
+		}
+		if r.done {
+			return nil
+		}
+		if err := fn(r.rank); err != nil {
+			return err
+		}
+	}
+}
+
+// boards returns up to limit boards of layer d.
+func (s *frontierSearch) boards(d, limit int) ([][]int, error) {
+	var boards [][]int
+	errLimit := errors.New("limit reached")
+	err := s.eachRank(d, func(rank int) error {
+		board := make([]int, s.rows*s.cols)
+		s.ranker.unrank(rank, board)
+		boards = append(boards, board)
+		if len(boards) == limit {
+			return errLimit
+		}
+		return nil
+	})
+	if err != nil && err != errLimit {
+		return nil, err
+	}
+	return boards, nil
+}
+
+// writeLayer writes the sorted ranks as layer d.
+func (s *frontierSearch) writeLayer(d int, ranks []int) error {
+	tmp := s.layerPath(d) + ".tmp"
+	if err := writeRanks(tmp, ranks); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.layerPath(d))
+}
+
+// saveMeta replaces meta.json atomically.
+func (s *frontierSearch) saveMeta() error {
+	data, err := json.Marshal(s.meta)
+	if err != nil {
+		return err
+	}
+	tmp := s.metaPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.metaPath())
+}
+
+func (s *frontierSearch) metaPath() string {
+	return filepath.Join(s.dir, "meta.json")
+}
+
+func (s *frontierSearch) layerPath(d int) string {
+	return filepath.Join(s.dir, fmt.Sprintf("layer-%04d.ranks", d))
+}
+
+// rankWriter writes increasing ranks to a file as uvarint deltas.
+type rankWriter struct {
+	f     *os.File
+	w     *bufio.Writer
+	prev  int
+	count int
+}
+
+func createRanks(name string) (*rankWriter, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return &rankWriter{f: f, w: bufio.NewWriter(f)}, nil
+}
+
+func (w *rankWriter) write(rank int) error {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(rank-w.prev))
+	w.prev = rank
+	w.count++
+	_, err := w.w.Write(buf[:n])
+	return err
+}
+
+// commit flushes the file and renames it to name.
+func (w *rankWriter) commit(name string) error {
+	if err := w.w.Flush(); err != nil {
+		return err
+	}
+	if err := w.f.Close(); err != nil {
+		return err
+	}
+	tmp := w.f.Name()
+	w.f = nil
+	return os.Rename(tmp, name)
+}
+
+// abort removes the file unless it was committed.
+func (w *rankWriter) abort() {
+	if w.f != nil {
+		w.f.Close()
+		os.Remove(w.f.Name())
+	}
+}
+
+// writeRanks writes sorted, distinct ranks to the file name.
+func writeRanks(name string, ranks []int) error {
+	w, err := createRanks(name)
+	if err != nil {
+		return err
+	}
+	for _, r := range ranks {
+		if err := w.write(r); err != nil {
+			w.abort()
+			return err
+		}
+	}
+	if err := w.w.Flush(); err != nil {
+		w.abort()
+		return err
+	}
+	return w.f.Close()
+}
+
+// rankReader reads a file written by rankWriter. After next, rank holds the next
+// rank unless done is set.
+type rankReader struct {
+	f    *os.File
+	r    *bufio.Reader
+	rank int
+	done bool
+}
+
+func openRanks(name string) (*rankReader, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &rankReader{f: f, r: bufio.NewReader(f)}, nil
+}
+
+func (r *rankReader) next() error {
+	delta, err := binary.ReadUvarint(r.r)
+	if err == io.EOF {
+		r.done = true
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", r.f.Name(), err)
+	}
+	r.rank += int(delta)
+	return nil
+}
+
+func (r *rankReader) close() {
+	r.f.Close()
+}
+
+// rankHeap orders readers by their current rank.
+type rankHeap []*rankReader
+
+func (h rankHeap) Len() int           { return len(h) }
+func (h rankHeap) Less(i, j int) bool { return h[i].rank < h[j].rank }
+func (h rankHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *rankHeap) Push(x any)        { *h = append(*h, x.(*rankReader)) }
+func (h *rankHeap) Pop() any {
+	old := *h
+	r := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return r
+}
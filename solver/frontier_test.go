@@ -0,0 +1,126 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestExplore_OnDisk(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ExploreOptions
+	}{
+		{"2x2", ExploreOptions{Rows: 2, Cols: 2}},
+		{"2x3 small runs", ExploreOptions{Rows: 2, Cols: 3, RunSize: 7}},
+		{"3x3", ExploreOptions{Rows: 3, Cols: 3, RunSize: 1 << 14}},
+		{"3x3 blank in the center", ExploreOptions{Rows: 3, Cols: 3, Goal: []int{1, 2, 3, 4, 9, 5, 6, 7, 8}, RunSize: 1 << 14, MaxAntipodes: 200}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := Explore(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatalf("Explore() in memory error = %v", err)
+			}
+			opts := tt.opts
+			opts.Dir = t.TempDir()
+			got, err := Explore(context.Background(), opts)
+			if err != nil {
+				t.Fatalf("Explore() on disk error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Explore() on disk = %+v, in memory %+v", got, want)
+			}
+			// Only the last two layers are kept.
+			layers, _ := filepath.Glob(filepath.Join(opts.Dir, "layer-*"))
+			if len(layers) > 2 {
+				t.Errorf("%d layer files left, want at most 2", len(layers))
+			}
+		})
+	}
+}
+
+func TestExplore_OnDiskResume(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	var seen []int
+	opts := ExploreOptions{Rows: 2, Cols: 3, Dir: dir, RunSize: 16, Progress: func(distance, count int) {
+		seen = append(seen, distance)
+		if distance == 10 {
+			cancel()
+		}
+	}}
+	if _, err := Explore(ctx, opts); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Explore() error = %v, want %v", err, context.Canceled)
+	}
+	// A stray run from the interrupted layer is ignored.
+	if err := os.WriteFile(filepath.Join(dir, "run-0012-0000.tmp"), []byte{0xff}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := Explore(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("resumed Explore() error = %v", err)
+	}
+	if e.States != 360 || e.MaxDistance != 21 {
+		t.Errorf("resumed Explore() = %d states, max distance %d; want 360, 21", e.States, e.MaxDistance)
+	}
+	// The resumed search starts with the layer that was interrupted.
+	for i, d := range seen {
+		if d != i+1 || len(seen) != 21 {
+			t.Fatalf("Progress distances = %v, want 1..21", seen)
+		}
+	}
+
+	// A finished search is read back without searching.
+	seen = nil
+	if again, err := Explore(context.Background(), opts); err != nil || !reflect.DeepEqual(again, e) || len(seen) != 0 {
+		t.Errorf("Explore() after completion = %+v, %v with progress %v; want %+v", again, err, seen, e)
+	}
+}
+
+func TestExplore_OnDiskErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Explore(context.Background(), ExploreOptions{Rows: 2, Cols: 2, Dir: dir}); err != nil {
+		t.Fatalf("Explore() error = %v", err)
+	}
+	if _, err := Explore(context.Background(), ExploreOptions{Rows: 2, Cols: 3, Dir: dir}); err == nil {
+		t.Error("Explore() with a directory of another puzzle succeeded")
+	}
+	if _, err := Explore(context.Background(), ExploreOptions{Rows: 3, Cols: 7, Dir: t.TempDir()}); err != ErrExploreTooLarge {
+		t.Errorf("Explore(3x7) error = %v, want %v", err, ErrExploreTooLarge)
+	}
+}
+
+func TestRankFiles(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "ranks")
+	want := []int{0, 3, 4, 300, 1 << 40}
+	if err := writeRanks(name, want); err != nil {
+		t.Fatalf("writeRanks() error = %v", err)
+	}
+	r, err := openRanks(name)
+	if err != nil {
+		t.Fatalf("openRanks() error = %v", err)
+	}
+	defer r.close()
+	var got []int
+	for {
+		if err := r.next(); err != nil {
+			t.Fatalf("next() error = %v", err)
+		}
+		if r.done {
+			break
+		}
+		got = append(got, r.rank)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("read %v, want %v", got, want)
+	}
+	if info, _ := os.Stat(name); info.Size() != 1+1+1+2+6 {
+		t.Errorf("file holds %d bytes, want 11", info.Size())
+	}
+}
@@ -0,0 +1,246 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	ErrInvalidRange = errors.New("invalid move range")
+	ErrNoPuzzles    = errors.New("no new puzzles found in the move range")
+)
+
+// DefaultMaxAttempts is the number of candidates Generate tries in a row without finding
+// a new puzzle when GenerateOptions.MaxAttempts is zero.
+const DefaultMaxAttempts = 100000
+
+// GenerateOptions configures Generate.
+type GenerateOptions struct {
+	Rows int
+	Cols int
+	// Goal is the configuration the generated puzzles are solved towards.
+	// StandardGoal(Rows, Cols) is used when Goal is nil.
+	Goal []int
+	// Count is the number of puzzles to generate.
+	Count int
+	// MinMoves and MaxMoves bound the optimal solution length (inclusive); MaxMoves may
+	// not exceed MoveBound(Rows, Cols).
+	MinMoves int
+	MaxMoves int
+	// Workers is the number of concurrent generators. runtime.NumCPU() is used when Workers <= 0.
+	Workers int
+	// Seed seeds the random scrambles. A random seed is used when Seed is zero.
+	Seed uint64
+	// Unique rejects boards that were already generated.
+	Unique bool
+	// ExcludeSymmetric rejects boards that are images of already generated boards under
+	// a symmetry of the goal (see Symmetries). It implies Unique.
+	ExcludeSymmetric bool
+	// MaxAttempts bounds the candidates tried in a row without finding a new puzzle, which
+	// ends the search for ranges beyond the largest distance of the board or with fewer
+	// distinct boards than Count; DefaultMaxAttempts is used when it is zero.
+	MaxAttempts int
+}
+
+// Puzzle is a generated start configuration together with its optimal solution length.
+type Puzzle struct {
+	Board []int
+	Moves int
+}
+
+// Generate produces opts.Count boards whose optimal distance to the goal lies in
+// [opts.MinMoves, opts.MaxMoves].
+// Candidates are made by random walks from the goal and checked with Solve (rejection sampling).
+// Generation stops early with ctx.Err() when ctx is done, and with ErrNoPuzzles after
+// opts.MaxAttempts candidates in a row fail to give a new puzzle; the puzzles found so
+// far are returned with the error.
+//
+// Example:
+//
+//	puzzles, err := Generate(ctx, GenerateOptions{Rows: 3, Cols: 3, Count: 20, MinMoves: 22, MaxMoves: 22})
+func Generate(ctx context.Context, opts GenerateOptions) ([]Puzzle, error) {
+	if err := validateShape(opts.Rows, opts.Cols); err != nil {
+		return nil, err
+	}
+	goal := opts.Goal
+	if goal == nil {
+		goal = StandardGoal(opts.Rows, opts.Cols)
+	}
+	if err := validate(goal, opts.Rows, opts.Cols); err != nil {
+		return nil, err
+	}
+	if opts.MinMoves < 0 || opts.MaxMoves < opts.MinMoves {
+		return nil, ErrInvalidRange
+	}
+	if bound := MoveBound(opts.Rows, opts.Cols); opts.MaxMoves > bound {
+		return nil, fmt.Errorf("%w: %d moves is more than the %d a %dx%d board can need", ErrInvalidRange, opts.MaxMoves, bound, opts.Rows, opts.Cols)
+	}
+	if opts.Count <= 0 {
+		return nil, nil
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// misses counts the candidates since the last new puzzle; too many end the search.
+	var misses atomic.Int64
+	var exhausted atomic.Bool
+	miss := func() {
+		if misses.Add(1) >= int64(maxAttempts) {
+			exhausted.Store(true)
+			cancel()
+		}
+	}
+
+	candidates := make(chan Puzzle)
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rng := rand.New(rand.NewPCG(seed, uint64(w)))
+			for ctx.Err() == nil {
+				board, err := scramble(ctx, goal, opts.Rows, opts.Cols, walkLength(rng, opts.MinMoves, opts.MaxMoves), rng)
+				if err != nil {
+					continue
+				}
+				path, err := SolveContext(ctx, board, goal, opts.Rows, opts.Cols)
+				if err != nil {
+					continue
+				}
+				moves := len(path) - 1
+				if moves < opts.MinMoves || moves > opts.MaxMoves {
+					miss()
+					continue
+				}
+				select {
+				case candidates <- Puzzle{Board: board, Moves: moves}:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(candidates)
+	}()
+
+	syms := goalSymmetries(goal, opts.Rows, opts.Cols)
+	seen := make(map[string]struct{})
+	puzzles := make([]Puzzle, 0, opts.Count)
+	for p := range candidates {
+		if opts.Unique || opts.ExcludeSymmetric {
+			board := p.Board
+			if opts.ExcludeSymmetric {
+				board, _ = canonicalize(board, syms)
+			}
+			key := boardKey(board)
+			if _, ok := seen[key]; ok {
+				miss()
+				continue
+			}
+			seen[key] = struct{}{}
+		}
+		misses.Store(0)
+		puzzles = append(puzzles, p)
+		if len(puzzles) == opts.Count {
+			cancel()
+			break
+		}
+	}
+	if len(puzzles) < opts.Count {
+		if exhausted.Load() && parent.Err() == nil {
+			return puzzles, ErrNoPuzzles
+		}
+		return puzzles, parent.Err()
+	}
+	// Drain so that the workers can observe the cancellation and exit.
+	for range candidates {
+	}
+	return puzzles, nil
+}
+
+// MoveBound returns the largest MaxMoves Generate accepts for a rows x cols board,
+// 4*rows*cols*(rows+cols). It is well above the 5n³+O(n²) moves known to solve any
+// n x n board, and saturates at math.MaxInt/2 so that random walks up to twice as long
+// still fit in an int.
+//
+// Example:
+//
+//	MoveBound(3, 3) // returns 216
+func MoveBound(rows, cols int) int {
+	const limit = math.MaxInt / 2
+	if rows < 2 || cols < 2 || rows > limit/cols || rows+cols > limit/4 || rows*cols > limit/(4*(rows+cols)) {
+		return limit
+	}
+	return 4 * rows * cols * (rows + cols)
+}
+
+// walkLength picks the length of a random walk for a target range of optimal distances.
+// Walks revisit states, so lengths beyond maxMoves are needed to reach the upper end of the range.
+// The span is computed in uint64 so that it cannot overflow for any maxMoves.
+func walkLength(rng *rand.Rand, minMoves, maxMoves int) int {
+	return minMoves + int(rng.Uint64N(2*uint64(maxMoves)-uint64(minMoves)+1))
+}
+
+// scramble returns the board reached from goal by a random walk of the blank tile
+// that never immediately undoes its previous move. It stops with ctx.Err() when ctx
+// is done.
+func scramble(ctx context.Context, goal []int, rows, cols, steps int, rng *rand.Rand) ([]int, error) {
+	n := newNode(goal, rows, cols)
+	prev := -1
+	for i := range steps {
+		if i%1024 == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		dirs := slices.DeleteFunc(n.directions(), func(dir int) bool { return dir == opposite(prev) })
+		prev = dirs[rng.IntN(len(dirs))]
+		n.moveBlank(prev)
+	}
+	return n.board, nil
+}
+
+// opposite returns the direction that undoes a move in dir, or -1 for no direction.
+func opposite(dir int) int {
+	switch dir {
+	case up:
+		return down
+	case down:
+		return up
+	case left:
+		return right
+	case right:
+		return left
+	}
+	return -1
+}
+
+// boardKey encodes a board as a string usable as a map key.
+func boardKey(board []int) string {
+	key := make([]byte, 0, len(board)*2)
+	for _, v := range board {
+		key = append(key, byte(v>>8), byte(v))
+	}
+	return string(key)
+}
@@ -0,0 +1,128 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name string
+		opts GenerateOptions
+	}{
+		{"2x2 exact", GenerateOptions{Rows: 2, Cols: 2, Count: 3, MinMoves: 4, MaxMoves: 4, Seed: 1}},
+		{"3x3 range", GenerateOptions{Rows: 3, Cols: 3, Count: 5, MinMoves: 10, MaxMoves: 12, Workers: 2, Seed: 2}},
+		{"3x3 unique", GenerateOptions{Rows: 3, Cols: 3, Count: 5, MinMoves: 4, MaxMoves: 4, Seed: 3, Unique: true}},
+		{"3x3 symmetric", GenerateOptions{Rows: 3, Cols: 3, Count: 2, MinMoves: 2, MaxMoves: 2, Seed: 4, ExcludeSymmetric: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			puzzles, err := Generate(context.Background(), tt.opts)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if len(puzzles) != tt.opts.Count {
+				t.Fatalf("Generate() returned %d puzzles, want %d", len(puzzles), tt.opts.Count)
+			}
+			goal := StandardGoal(tt.opts.Rows, tt.opts.Cols)
+			syms := goalSymmetries(goal, tt.opts.Rows, tt.opts.Cols)
+			seen := make(map[string]bool)
+			canonicals := make(map[string]bool)
+			for _, p := range puzzles {
+				path, err := Solve(p.Board, goal, tt.opts.Rows, tt.opts.Cols)
+				if err != nil {
+					t.Fatalf("Solve(%v) error = %v", p.Board, err)
+				}
+				if len(path)-1 != p.Moves || p.Moves < tt.opts.MinMoves || p.Moves > tt.opts.MaxMoves {
+					t.Errorf("puzzle %v: Moves = %d, optimal = %d", p.Board, p.Moves, len(path)-1)
+				}
+				if tt.opts.Unique || tt.opts.ExcludeSymmetric {
+					if seen[boardKey(p.Board)] {
+						t.Errorf("duplicate puzzle %v", p.Board)
+					}
+					seen[boardKey(p.Board)] = true
+				}
+				if tt.opts.ExcludeSymmetric {
+					canonical, _ := canonicalize(p.Board, syms)
+					if canonicals[boardKey(canonical)] {
+						t.Errorf("a symmetric image of %v was also generated", p.Board)
+					}
+					canonicals[boardKey(canonical)] = true
+				}
+			}
+		})
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    GenerateOptions
+		want    int
+		wantErr error
+	}{
+		{"invalid range", GenerateOptions{Rows: 2, Cols: 2, Count: 1, MinMoves: 5, MaxMoves: 4}, 0, ErrInvalidRange},
+		{"negative rows", GenerateOptions{Rows: -1, Cols: 3, Count: 1}, 0, ErrInvalidSize},
+		{"negative moves", GenerateOptions{Rows: 2, Cols: 2, Count: 1, MinMoves: -1, MaxMoves: 4}, 0, ErrInvalidRange},
+		{"more moves than the bound", GenerateOptions{Rows: 2, Cols: 2, Count: 1, MaxMoves: 65}, 0, ErrInvalidRange},
+		{"overflowing walk", GenerateOptions{Rows: 3, Cols: 3, Count: 1, MaxMoves: 1 << 62}, 0, ErrInvalidRange},
+		// No 2x2 board is more than 6 moves from the goal.
+		{"beyond the largest distance", GenerateOptions{Rows: 2, Cols: 2, Count: 1, MinMoves: 7, MaxMoves: 8, Seed: 1}, 0, ErrNoPuzzles},
+		// A 2x2 puzzle has 12 solvable boards.
+		{"more than the distinct boards", GenerateOptions{Rows: 2, Cols: 2, Count: 13, MaxMoves: 6, Seed: 2, Unique: true, MaxAttempts: 1000}, 12, ErrNoPuzzles},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			puzzles, err := Generate(context.Background(), tt.opts)
+			if !errors.Is(err, tt.wantErr) || len(puzzles) != tt.want {
+				t.Errorf("Generate() = %d puzzles, %v; want %d, %v", len(puzzles), err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerate_Deadline(t *testing.T) {
+	// Solving 10x10 boards scrambled up to the bound only ends at the deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := Generate(ctx, GenerateOptions{Rows: 10, Cols: 10, Count: 1, MinMoves: MoveBound(10, 10), MaxMoves: MoveBound(10, 10), Workers: 2, Seed: 1})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Generate() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Generate() returned after %v", elapsed)
+	}
+}
+
+func TestMoveBound(t *testing.T) {
+	tests := []struct {
+		rows, cols int
+		want       int
+	}{
+		{2, 2, 64},
+		{3, 3, 216},
+		{4, 4, 512},
+		{2, 5, 280},
+		{1 << 30, 1 << 30, math.MaxInt / 2},
+		{1 << 61, 2, math.MaxInt / 2},
+	}
+	for _, tt := range tests {
+		if got := MoveBound(tt.rows, tt.cols); got != tt.want {
+			t.Errorf("MoveBound(%d, %d) = %d, want %d", tt.rows, tt.cols, got, tt.want)
+		}
+	}
+}
+
+func TestScramble_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rng := rand.New(rand.NewPCG(1, 2))
+	if _, err := scramble(ctx, StandardGoal(3, 3), 3, 3, math.MaxInt/2, rng); !errors.Is(err, context.Canceled) {
+		t.Errorf("scramble() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,513 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var ErrInvalidGraph = errors.New("invalid graph")
+
+// Graph is the board of a sliding puzzle played on the vertices of an undirected graph,
+// also known as pebble motion: each vertex holds a tile, one holds the blank, and a tile
+// may move to the blank along an edge. Grids and shapes are special cases; see GridGraph
+// and Shape.Graph.
+//
+// Boards on a graph of n vertices hold the numbers from 1 to n, one per vertex, and n is
+// the blank.
+type Graph struct {
+	// Adj lists the neighbors of each vertex. Every edge appears in the lists of both of
+	// its ends.
+	Adj [][]int `json:"adj"`
+}
+
+// GridGraph returns the graph of a rows x cols board, whose vertices are the cells in
+// row-major order, so that boards and paths mean the same as for Solve.
+//
+// Example:
+//
+//	GridGraph(2, 2).Adj // [[2 1] [3 0] [0 3] [1 2]]
+func GridGraph(rows, cols int) Graph {
+	return Graph{Adj: gridGraph(rows, cols, nil).adj}
+}
+
+// validate checks that the graph has at least two vertices and that its neighbor lists
+// describe an undirected graph without loops or repeated edges.
+func (g Graph) validate() error {
+	if len(g.Adj) < 2 {
+		return fmt.Errorf("%w: fewer than 2 vertices", ErrInvalidGraph)
+	}
+	for v, adj := range g.Adj {
+		for i, w := range adj {
+			switch {
+			case w < 0 || w >= len(g.Adj):
+				return fmt.Errorf("%w: vertex %d has neighbor %d out of range", ErrInvalidGraph, v, w)
+			case w == v:
+				return fmt.Errorf("%w: vertex %d is its own neighbor", ErrInvalidGraph, v)
+			case slices.Contains(adj[:i], w):
+				return fmt.Errorf("%w: vertex %d lists neighbor %d twice", ErrInvalidGraph, v, w)
+			case !slices.Contains(g.Adj[w], v):
+				return fmt.Errorf("%w: vertex %d is a neighbor of %d but not the other way around", ErrInvalidGraph, w, v)
+			}
+		}
+	}
+	return nil
+}
+
+// validateBoard checks that board is a valid configuration of the graph.
+func (g Graph) validateBoard(board []int) error {
+	if len(board) == 0 {
+		return ErrEmptyBoard
+	}
+	if len(board) != len(g.Adj) {
+		return &ValidationError{Err: ErrSizeMismatch, ExpectedLength: len(g.Adj), ActualLength: len(board)}
+	}
+	return validateElements(board)
+}
+
+// graph returns the move graph, leaving out the vertices marked in locked when it is non-nil.
+func (g Graph) graph(locked []bool) *graph {
+	m := &graph{adj: make([][]int, len(g.Adj))}
+	for v, adj := range g.Adj {
+		if locked != nil && locked[v] {
+			continue
+		}
+		for _, w := range adj {
+			if locked == nil || !locked[w] {
+				m.adj[v] = append(m.adj[v], w)
+			}
+		}
+	}
+	return m
+}
+
+// GraphSolvable reports whether start can reach goal on the graph, using Wilson's
+// theorem: within a 2-connected part, a bipartite graph reaches the even permutations,
+// any other graph every permutation, except for cycles, which can only rotate, and the
+// exceptional theta graph θ0. Graphs that are not 2-connected are decided part by part.
+//
+// Example:
+//
+//	// A triangle with a tail: taking the blank around the triangle swaps its two tiles.
+//	g := Graph{Adj: [][]int{{1, 2}, {0, 2}, {0, 1, 3}, {2}}}
+//	ok, err := GraphSolvable([]int{2, 1, 3, 4}, StandardGoal(1, 4), g) // true
+func GraphSolvable(start, goal []int, g Graph) (bool, error) {
+	if err := g.validate(); err != nil {
+		return false, err
+	}
+	if err := g.validateBoard(start); err != nil {
+		return false, err
+	}
+	if err := g.validateBoard(goal); err != nil {
+		return false, err
+	}
+	return g.graph(nil).solvable(start, goal), nil
+}
+
+// SolveGraph finds a shortest solution of a puzzle on a graph with IDA*, estimating the
+// moves left by the sum of the tiles' shortest-path distances to their goal vertices. It
+// returns the vertices the blank visits, including its initial vertex.
+//
+// opts.Stats and opts.Progress work as for SolveWithOptions; opts.Locked, if set, has
+// one entry per vertex. Tablebases and opts.Cache are not used.
+//
+// Example:
+//
+//	g := Graph{Adj: [][]int{{1, 2}, {0, 2}, {0, 1, 3}, {2}}}
+//	path, err := SolveGraph(ctx, []int{2, 1, 3, 4}, StandardGoal(1, 4), g, SolveOptions{})
+func SolveGraph(ctx context.Context, start, goal []int, g Graph, opts SolveOptions) ([]int, error) {
+	if err := g.validate(); err != nil {
+		return nil, err
+	}
+	if err := g.validateBoard(start); err != nil {
+		return nil, err
+	}
+	if err := g.validateBoard(goal); err != nil {
+		return nil, err
+	}
+	if opts.Locked != nil {
+		if err := validateLocked(start, goal, opts.Locked); err != nil {
+			return nil, err
+		}
+	}
+	m := g.graph(opts.Locked)
+	return solveGraph(ctx, start, goal, m, newGraphGoal(goal, m), opts)
+}
+
+// graph is the move graph of a puzzle: the blank can move from a cell to each of its
+// neighbors. Cells without neighbors, such as locked cells, never change.
+type graph struct {
+	adj [][]int
+}
+
+// gridGraph returns the move graph of a rows x cols board whose locked cells, when
+// locked is non-nil, are left out.
+func gridGraph(rows, cols int, locked []bool) *graph {
+	return wrappedGrid(rows, cols, NoWrap, locked)
+}
+
+// wrappedGrid is gridGraph for boards whose moves wrap around as selected by wrap. The
+// neighbors of a cell are listed up, down, left and right, like node.directions.
+func wrappedGrid(rows, cols int, wrap Wrap, locked []bool) *graph {
+	g := &graph{adj: make([][]int, rows*cols)}
+	n := &node{board: make([]int, rows*cols), rows: rows, cols: cols, locked: locked, wrap: wrap}
+	for i := range g.adj {
+		if n.isLocked(i) {
+			continue
+		}
+		n.blankIdx = i
+		for _, dir := range n.directions() {
+			g.adj[i] = append(g.adj[i], n.neighbor(dir))
+		}
+	}
+	return g
+}
+
+// unreachableDistance is the distance between vertices of different components.
+const unreachableDistance = -1
+
+// distances returns the shortest-path distance between every pair of vertices,
+// or unreachableDistance, by a breadth-first search from each vertex.
+func (g *graph) distances() [][]int {
+	dist := make([][]int, len(g.adj))
+	for v := range g.adj {
+		dist[v] = g.bfs(v)
+	}
+	return dist
+}
+
+// bfs returns the distance of every vertex from v, or unreachableDistance.
+func (g *graph) bfs(v int) []int {
+	dist := make([]int, len(g.adj))
+	for i := range dist {
+		dist[i] = unreachableDistance
+	}
+	dist[v] = 0
+	queue := []int{v}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		for _, w := range g.adj[u] {
+			if dist[w] == unreachableDistance {
+				dist[w] = dist[u] + 1
+				queue = append(queue, w)
+			}
+		}
+	}
+	return dist
+}
+
+// path returns a shortest path of vertices from u to v, both included, or nil when v
+// cannot be reached.
+func (g *graph) path(u, v int) []int {
+	dist := g.bfs(v)
+	if dist[u] == unreachableDistance {
+		return nil
+	}
+	path := []int{u}
+	for u != v {
+		for _, w := range g.adj[u] {
+			if dist[w] == dist[u]-1 {
+				u = w
+				break
+			}
+		}
+		path = append(path, u)
+	}
+	return path
+}
+
+// block is a biconnected component of a graph as seen from a root vertex: the blank
+// enters it through entry, the vertex of the block closest to the root.
+type block struct {
+	entry    int
+	vertices []int // including entry
+}
+
+// blocks returns the biconnected components of the component of root, using
+// Tarjan's algorithm. Bridges are blocks of two vertices.
+func (g *graph) blocks(root int) []block {
+	n := len(g.adj)
+	disc := make([]int, n)
+	low := make([]int, n)
+	for i := range disc {
+		disc[i] = -1
+	}
+	var blocks []block
+	var stack []int // vertices of the blocks being discovered
+	time := 0
+
+	var visit func(u, parent int)
+	visit = func(u, parent int) {
+		disc[u], low[u] = time, time
+		time++
+		stack = append(stack, u)
+		for _, w := range g.adj[u] {
+			if w == parent {
+				continue
+			}
+			if disc[w] != -1 {
+				low[u] = min(low[u], disc[w])
+				continue
+			}
+			visit(w, u)
+			low[u] = min(low[u], low[w])
+			if low[w] >= disc[u] {
+				// u separates w's subtree: pop the block up to w and add u as its entry.
+				i := slices.Index(stack, w)
+				b := block{entry: u, vertices: append([]int{u}, stack[i:]...)}
+				stack = stack[:i]
+				blocks = append(blocks, b)
+			}
+		}
+	}
+	visit(root, -1)
+	return blocks
+}
+
+// solvable decides whether start can reach goal by moving the blank, the value
+// len(start), along the graph.
+//
+// Tiles outside the blank's component never move. Within it, moving the blank to the
+// goal's blank cell r reduces the question to whether the permutation taking the start
+// to the goal lies in the group of blank tours from r. A tour only permutes tiles within
+// a biconnected component, apart from the shifts along the way that it undoes, so this
+// group is the direct product, over the blocks, of the tours of each block from its
+// entry, acting on the block's other vertices. By Wilson's theorem, the tours of a block
+// that is neither a cycle nor the exceptional theta graph θ0 give every permutation if
+// the block is not bipartite and the even ones if it is; the tours of a cycle rotate it.
+func (g *graph) solvable(start, goal []int) bool {
+	board, r := g.settle(start, goal)
+	if board == nil {
+		return false
+	}
+	goalPos := make([]int, len(board)+1)
+	for v, t := range goal {
+		goalPos[t] = v
+	}
+	// sigma[v] is the cell the tile at v has to reach.
+	sigma := make([]int, len(board))
+	for v, t := range board {
+		sigma[v] = goalPos[t]
+	}
+
+	for _, b := range g.blocks(r) {
+		if !g.blockContains(b, sigma) {
+			return false
+		}
+	}
+	return true
+}
+
+// settle moves the blank of start along a shortest path to the goal's blank cell r and
+// returns the resulting board and r, or nil when the blank cannot get there or a tile
+// outside r's component, where tiles never move, is not in its goal cell.
+func (g *graph) settle(start, goal []int) ([]int, int) {
+	blank := len(start)
+	sb, r := slices.Index(start, blank), slices.Index(goal, blank)
+	route := g.path(sb, r)
+	if route == nil {
+		return nil, r
+	}
+	board := slices.Clone(start)
+	for i := 1; i < len(route); i++ {
+		board[route[i-1]], board[route[i]] = board[route[i]], board[route[i-1]]
+	}
+	for v, d := range g.bfs(r) {
+		if d == unreachableDistance && board[v] != goal[v] {
+			return nil, r
+		}
+	}
+	return board, r
+}
+
+// blockKind classifies a block by the group of its tours from the entry.
+type blockKind int
+
+const (
+	bridgeBlock    blockKind = iota // a single edge, whose tours permute nothing
+	cycleBlock                      // tours rotate the other vertices
+	bipartiteBlock                  // tours give the even permutations
+	thetaBlock                      // θ0, whose tours are searched exhaustively
+	fullBlock                       // tours give every permutation
+)
+
+// kind returns the kind of block b, whose vertices are those of inBlock.
+func (g *graph) kind(b block, inBlock map[int]bool) blockKind {
+	edges := 0
+	cycle := true
+	var branches []int // vertices of degree 3 or more within the block
+	for _, v := range b.vertices {
+		degree := 0
+		for _, w := range g.adj[v] {
+			if inBlock[w] {
+				degree++
+			}
+		}
+		edges += degree
+		cycle = cycle && degree == 2
+		if degree > 2 {
+			branches = append(branches, v)
+		}
+	}
+	edges /= 2
+
+	switch {
+	case len(b.vertices) <= 2:
+		return bridgeBlock
+	case cycle:
+		return cycleBlock
+	case g.bipartite(b.vertices, inBlock):
+		return bipartiteBlock
+	case len(b.vertices) == 7 && edges == 8 && !slices.Contains(g.adj[branches[0]], branches[1]):
+		// This is θ0, two vertices joined by paths through 1, 2 and 2 others: the only
+		// non-bipartite block whose tours miss some permutations.
+		return thetaBlock
+	default:
+		return fullBlock
+	}
+}
+
+// vertexSet returns the vertices of b as a set.
+func (b block) vertexSet() map[int]bool {
+	inBlock := make(map[int]bool, len(b.vertices))
+	for _, v := range b.vertices {
+		inBlock[v] = true
+	}
+	return inBlock
+}
+
+// blockContains reports whether the tours of block b from its entry realize sigma on
+// the block's other vertices.
+func (g *graph) blockContains(b block, sigma []int) bool {
+	members := b.vertices[1:]
+	for _, v := range members {
+		if !slices.Contains(members, sigma[v]) {
+			return false
+		}
+	}
+	inBlock := b.vertexSet()
+
+	switch g.kind(b, inBlock) {
+	case cycleBlock:
+		return isRotation(g.cycleOrder(b, inBlock), sigma)
+	case bipartiteBlock:
+		local := make([]int, len(members))
+		for i, v := range members {
+			local[i] = slices.Index(members, sigma[v])
+		}
+		return permutationParity(local) == 0
+	case thetaBlock:
+		// The tile starting on vertex i is labeled i, which makes the entry, index 0,
+		// the blank.
+		start := make([]byte, len(b.vertices))
+		target := make([]byte, len(b.vertices))
+		for i, v := range b.vertices {
+			start[i] = byte(i)
+			if i > 0 {
+				target[slices.Index(b.vertices, sigma[v])] = byte(i)
+			}
+		}
+		return g.blockReaches(b, inBlock, start, target)
+	default:
+		return true
+	}
+}
+
+// cycleOrder returns the vertices of a cycle block other than its entry, in order along
+// the cycle.
+func (g *graph) cycleOrder(b block, inBlock map[int]bool) []int {
+	var order []int
+	prev, v := b.entry, -1
+	for _, w := range g.adj[b.entry] {
+		if inBlock[w] {
+			v = w
+			break
+		}
+	}
+	for v != b.entry {
+		order = append(order, v)
+		for _, w := range g.adj[v] {
+			if inBlock[w] && w != prev {
+				prev, v = v, w
+				break
+			}
+		}
+	}
+	return order
+}
+
+// isRotation reports whether sigma moves the vertices of order, a cycle without its
+// entry, by the same number of steps along the cycle.
+func isRotation(order, sigma []int) bool {
+	shift := slices.Index(order, sigma[order[0]])
+	for i, v := range order {
+		if sigma[v] != order[(i+shift)%len(order)] {
+			return false
+		}
+	}
+	return true
+}
+
+// bipartite reports whether the subgraph induced by vertices can be two-colored.
+func (g *graph) bipartite(vertices []int, inBlock map[int]bool) bool {
+	color := map[int]int{vertices[0]: 0}
+	queue := []int{vertices[0]}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		for _, w := range g.adj[u] {
+			if !inBlock[w] {
+				continue
+			}
+			if c, ok := color[w]; !ok {
+				color[w] = 1 - color[u]
+				queue = append(queue, w)
+			} else if c == color[u] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// blockReaches searches the arrangements of a small block exhaustively for a way from
+// start to target with the blank back at the entry. Both label the vertices of b, in
+// order, with 0 for the blank on the entry.
+func (g *graph) blockReaches(b block, inBlock map[int]bool, start, target []byte) bool {
+	// Work with indices into b.vertices.
+	m := len(b.vertices)
+	local := make(map[int]int, m)
+	for i, v := range b.vertices {
+		local[v] = i
+	}
+	adj := make([][]int, m)
+	for i, v := range b.vertices {
+		for _, w := range g.adj[v] {
+			if inBlock[w] {
+				adj[i] = append(adj[i], local[w])
+			}
+		}
+	}
+
+	seen := map[string]bool{string(start): true}
+	queue := [][]byte{start}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		if string(s) == string(target) {
+			return true
+		}
+		blank := slices.Index(s, 0)
+		for _, w := range adj[blank] {
+			next := slices.Clone(s)
+			next[blank], next[w] = next[w], next[blank]
+			if !seen[string(next)] {
+				seen[string(next)] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return false
+}
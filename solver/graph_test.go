@@ -0,0 +1,304 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// graphFromEdges returns the graph with n vertices and the given edges.
+func graphFromEdges(n int, edges [][2]int) *graph {
+	g := &graph{adj: make([][]int, n)}
+	for _, e := range edges {
+		g.adj[e[0]] = append(g.adj[e[0]], e[1])
+		g.adj[e[1]] = append(g.adj[e[1]], e[0])
+	}
+	return g
+}
+
+// reachableBoards returns the keys of every board reachable from board by moving the
+// blank along g, by breadth-first search.
+func reachableBoards(g *graph, board []int) map[string]bool {
+	seen := map[string]bool{boardKey(board): true}
+	queue := [][]int{board}
+	for len(queue) > 0 {
+		b := queue[0]
+		queue = queue[1:]
+		blank := slices.Index(b, len(b))
+		for _, w := range g.adj[blank] {
+			next := slices.Clone(b)
+			next[blank], next[w] = next[w], next[blank]
+			if key := boardKey(next); !seen[key] {
+				seen[key] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return seen
+}
+
+// checkSolvable compares g.solvable against a search for every arrangement of the
+// goal's tiles on the vertices with neighbors, the others staying in place.
+func checkSolvable(t *testing.T, name string, g *graph, goal []int) {
+	t.Helper()
+	reachable := reachableBoards(g, goal)
+	var cells []int
+	for v, adj := range g.adj {
+		if len(adj) > 0 {
+			cells = append(cells, v)
+		}
+	}
+	board := slices.Clone(goal)
+	solvable := 0
+	for r := range factorial(len(cells)) {
+		perm := unrankPartialPermutation(r, len(cells), len(cells))
+		for i, c := range cells {
+			board[c] = goal[cells[perm[i]]]
+		}
+		got := g.solvable(board, goal)
+		if want := reachable[boardKey(board)]; got != want {
+			t.Fatalf("%s: solvable(%v) = %v, want %v", name, board, got, want)
+		}
+		if got {
+			solvable++
+		}
+	}
+	if solvable != len(reachable) {
+		t.Errorf("%s: %d solvable boards, search reaches %d", name, solvable, len(reachable))
+	}
+}
+
+func TestGraph_SolvableGrids(t *testing.T) {
+	tests := []struct {
+		name   string
+		rows   int
+		cols   int
+		locked []int
+	}{
+		{"2x4", 2, 4, nil},
+		{"3x3 ring around the center", 3, 3, []int{4}},
+		{"2x4 ring with a tail", 2, 4, []int{1}},
+		{"3x3 tree", 3, 3, []int{3, 5}},
+		{"3x3 without a corner", 3, 3, []int{2}},
+		{"2x3 split in two", 2, 3, []int{1, 4}},
+		{"3x3 two rings on a bridge", 3, 4, []int{1, 4, 5, 6, 7, 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			locked := make([]bool, tt.rows*tt.cols)
+			for _, c := range tt.locked {
+				locked[c] = true
+			}
+			goal := StandardGoal(tt.rows, tt.cols)
+			checkSolvable(t, tt.name, gridGraph(tt.rows, tt.cols, locked), goal)
+			// A goal with the blank elsewhere exercises other roots.
+			goal[0], goal[len(goal)-1] = goal[len(goal)-1], goal[0]
+			checkSolvable(t, tt.name+" blank first", gridGraph(tt.rows, tt.cols, locked), goal)
+		})
+	}
+}
+
+func TestGraph_SolvableTheta(t *testing.T) {
+	tests := []struct {
+		name      string
+		edges     [][2]int
+		reachable int
+	}{
+		// θ0: paths of 1, 2 and 2 inner vertices between 0 and 1. Its tours form a group
+		// of order 120 on the 6 tiles, for 7 * 120 boards.
+		{"theta0", [][2]int{{0, 2}, {2, 1}, {0, 3}, {3, 4}, {4, 1}, {0, 5}, {5, 6}, {6, 1}}, 7 * 120},
+		// Another non-bipartite theta graph with 7 vertices gives every arrangement.
+		{"theta014", [][2]int{{0, 1}, {0, 2}, {2, 1}, {0, 3}, {3, 4}, {4, 5}, {5, 6}, {6, 1}}, 5040},
+		// A bipartite theta graph gives the even permutations.
+		{"theta222", [][2]int{{0, 2}, {2, 3}, {3, 1}, {0, 4}, {4, 5}, {5, 1}, {0, 6}, {6, 7}, {7, 1}}, 20160},
+		{"triangles sharing a vertex", [][2]int{{0, 1}, {1, 2}, {2, 0}, {2, 3}, {3, 4}, {4, 2}}, 5 * 2 * 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := 0
+			for _, e := range tt.edges {
+				n = max(n, e[0]+1, e[1]+1)
+			}
+			g := graphFromEdges(n, tt.edges)
+			goal := make([]int, n)
+			for i := range goal {
+				goal[i] = i + 1
+			}
+			if got := len(reachableBoards(g, goal)); got != tt.reachable {
+				t.Fatalf("search reaches %d boards, want %d", got, tt.reachable)
+			}
+			checkSolvable(t, tt.name, g, goal)
+		})
+	}
+}
+
+func TestGraph_Blocks(t *testing.T) {
+	// Two triangles sharing vertex 2, with a tail 4-5.
+	g := graphFromEdges(6, [][2]int{{0, 1}, {1, 2}, {2, 0}, {2, 3}, {3, 4}, {4, 2}, {4, 5}})
+	var got [][]int
+	for _, b := range g.blocks(0) {
+		vertices := slices.Clone(b.vertices[1:])
+		slices.Sort(vertices)
+		got = append(got, append([]int{b.entry}, vertices...))
+	}
+	slices.SortFunc(got, slices.Compare)
+	want := [][]int{{0, 1, 2}, {2, 3, 4}, {4, 5}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("blocks(0) = %v, want %v (entry first)", got, want)
+	}
+}
+
+func TestGraph_Distances(t *testing.T) {
+	// 3x3 with the center locked: opposite edge cells are 4 steps apart around the ring.
+	locked := make([]bool, 9)
+	locked[4] = true
+	dist := gridGraph(3, 3, locked).distances()
+	if dist[1][7] != 4 || dist[0][8] != 4 || dist[0][1] != 1 || dist[4][0] != unreachableDistance {
+		t.Errorf("distances = %v", dist)
+	}
+	if got := gridGraph(3, 3, locked).path(1, 3); len(got) != 3 || got[0] != 1 || got[2] != 3 {
+		t.Errorf("path(1, 3) = %v", got)
+	}
+}
+
+func TestGraph_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		adj     [][]int
+		wantErr bool
+	}{
+		{"triangle", [][]int{{1, 2}, {0, 2}, {0, 1}}, false},
+		{"isolated vertex", [][]int{{1}, {0}, nil}, false},
+		{"grid", GridGraph(2, 3).Adj, false},
+		{"one vertex", [][]int{nil}, true},
+		{"out of range", [][]int{{1}, {0, 2}}, true},
+		{"loop", [][]int{{0, 1}, {0}}, true},
+		{"repeated edge", [][]int{{1, 1}, {0}}, true},
+		{"one-way edge", [][]int{{1}, nil}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Graph{Adj: tt.adj}.validate()
+			if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, ErrInvalidGraph)) {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGraphSolvable(t *testing.T) {
+	triangleWithTail := Graph{Adj: [][]int{{1, 2}, {0, 2}, {0, 1, 3}, {2}}}
+	square := Graph{Adj: [][]int{{1, 3}, {0, 2}, {1, 3}, {0, 2}}}
+	// A square with a diagonal is 2-connected, not bipartite and not θ0.
+	diagonal := Graph{Adj: [][]int{{1, 3, 2}, {0, 2}, {1, 3, 0}, {0, 2}}}
+	tests := []struct {
+		name    string
+		start   []int
+		g       Graph
+		want    bool
+		wantErr error
+	}{
+		{"swap around the triangle", []int{2, 1, 3, 4}, triangleWithTail, true, nil},
+		{"tile behind the bridge", []int{1, 2, 4, 3}, triangleWithTail, true, nil},
+		{"tile on the bridge out of place", []int{3, 1, 2, 4}, triangleWithTail, false, nil},
+		{"square rotation", []int{3, 1, 2, 4}, square, true, nil},
+		{"square swap", []int{2, 1, 3, 4}, square, false, nil},
+		{"diagonal swap", []int{2, 1, 3, 4}, diagonal, true, nil},
+		{"wrong length", []int{1, 2, 3}, square, false, ErrSizeMismatch},
+		{"invalid graph", []int{1, 2}, Graph{Adj: [][]int{{1}, nil}}, false, ErrInvalidGraph},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goal := StandardGoal(1, max(len(tt.start), 2))
+			got, err := GraphSolvable(tt.start, goal, tt.g)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("GraphSolvable() = %v, %v; want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGraphSolvable_Grid(t *testing.T) {
+	// On a grid graph, Wilson's theorem agrees with the parity rule of Solve.
+	goal := StandardGoal(2, 3)
+	g := GridGraph(2, 3)
+	for r := range factorial(6) {
+		perm := unrankPartialPermutation(r, 6, 6)
+		board := make([]int, 6)
+		for i, p := range perm {
+			board[i] = p + 1
+		}
+		got, err := GraphSolvable(board, goal, g)
+		if want := isSolvable(board, goal, 2, 3); err != nil || got != want {
+			t.Fatalf("GraphSolvable(%v) = %v, %v; isSolvable = %v", board, got, err, want)
+		}
+	}
+}
+
+func TestSolveGraph(t *testing.T) {
+	petersen := graphFromEdges(10, [][2]int{
+		{0, 1}, {1, 2}, {2, 3}, {3, 4}, {4, 0},
+		{0, 5}, {1, 6}, {2, 7}, {3, 8}, {4, 9},
+		{5, 7}, {7, 9}, {9, 6}, {6, 8}, {8, 5},
+	})
+	tests := []struct {
+		name    string
+		start   []int
+		g       Graph
+		locked  []bool
+		wantErr error
+	}{
+		{"triangle with a tail", []int{2, 1, 3, 4}, Graph{Adj: [][]int{{1, 2}, {0, 2}, {0, 1, 3}, {2}}}, nil, nil},
+		{"theta0", []int{5, 6, 4, 2, 3, 1, 7}, Graph{Adj: graphFromEdges(7, [][2]int{{0, 2}, {2, 1}, {0, 3}, {3, 4}, {4, 1}, {0, 5}, {5, 6}, {6, 1}}).adj}, nil, nil},
+		{"petersen", []int{2, 1, 3, 4, 5, 6, 7, 8, 9, 10}, Graph{Adj: petersen.adj}, nil, nil},
+		{"petersen locked", []int{1, 3, 2, 4, 5, 6, 7, 8, 9, 10}, Graph{Adj: petersen.adj}, lockedMask(10, 0), nil},
+		{"grid", []int{4, 1, 3, 7, 2, 6, 5, 8, 9}, GridGraph(3, 3), nil, nil},
+		{"square swap", []int{2, 1, 3, 4}, Graph{Adj: [][]int{{1, 3}, {0, 2}, {1, 3}, {0, 2}}}, nil, ErrUnsolvable},
+		{"locked blank", []int{1, 2, 4, 3}, GridGraph(2, 2), lockedMask(4, 2), ErrLockedBlank},
+		{"invalid graph", []int{1, 2}, Graph{Adj: [][]int{{1}, nil}}, nil, ErrInvalidGraph},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goal := StandardGoal(1, len(tt.start))
+			path, err := SolveGraph(context.Background(), tt.start, goal, tt.g, SolveOptions{Locked: tt.locked})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SolveGraph() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			g := tt.g.graph(tt.locked)
+			board := slices.Clone(tt.start)
+			for i := 1; i < len(path); i++ {
+				if !slices.Contains(g.adj[path[i-1]], path[i]) {
+					t.Fatalf("path %v moves the blank from %d to %d, which are not neighbors", path, path[i-1], path[i])
+				}
+				board[path[i-1]], board[path[i]] = board[path[i]], board[path[i-1]]
+			}
+			if !slices.Equal(board, goal) {
+				t.Fatalf("path %v ends at %v", path, board)
+			}
+			if want := lockedDistance(g, tt.start, goal); len(path)-1 != want {
+				t.Errorf("path takes %d moves, want %d", len(path)-1, want)
+			}
+		})
+	}
+}
+
+func TestSolveGraph_Grid(t *testing.T) {
+	// A grid graph gives the same boards, paths and optimal lengths as Solve.
+	start := []int{8, 6, 7, 2, 5, 4, 3, 9, 1}
+	want, err := Solve(start, StandardGoal(3, 3), 3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := SolveGraph(context.Background(), start, StandardGoal(3, 3), GridGraph(3, 3), SolveOptions{})
+	if err != nil || len(got) != len(want) {
+		t.Fatalf("SolveGraph() = %d moves, %v; Solve takes %d", len(got)-1, err, len(want)-1)
+	}
+	if board := playPath(start, got); !slices.Equal(board, StandardGoal(3, 3)) {
+		t.Errorf("path %v ends at %v", got, board)
+	}
+}
@@ -0,0 +1,177 @@
+package solver
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultHintTimeout bounds the IDA* search Hint runs on boards too large for a distance table.
+const DefaultHintTimeout = 5 * time.Second
+
+// maxTableCells is the largest board, in cells, for which Hint builds a tablebase on its own.
+const maxTableCells = 9
+
+// HintResult describes the optimal continuations from a position.
+type HintResult struct {
+	// Distance is the number of moves remaining on a shortest path.
+	Distance int
+	// Moves lists the blank tile indices reachable in one move that keep the
+	// position on some shortest path. It is empty when the board is already solved.
+	Moves []int
+}
+
+// Hint returns every optimal next move from board together with the remaining distance.
+// Boards with a tablebase loaded by LoadTablebase are answered from it, and other boards
+// of up to 9 cells from a tablebase built once per goal and kept for the most recently
+// used goals; larger boards fall back to IDA* limited to DefaultHintTimeout.
+//
+// Example:
+//
+//	h, err := Hint([]int{1, 2, 4, 3}, []int{1, 2, 3, 4}, 2, 2)
+//	// h.Distance == 1, h.Moves == []int{3}
+func Hint(board, goal []int, rows, cols int) (HintResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultHintTimeout)
+	defer cancel()
+	return HintContext(ctx, board, goal, rows, cols)
+}
+
+// HintContext is like Hint but bounds the IDA* fallback, and the building of a table
+// for a new goal, by ctx instead of DefaultHintTimeout.
+func HintContext(ctx context.Context, board, goal []int, rows, cols int) (HintResult, error) {
+	if err := validate(board, rows, cols); err != nil {
+		return HintResult{}, err
+	}
+	if err := validate(goal, rows, cols); err != nil {
+		return HintResult{}, err
+	}
+
+	if !isSolvable(board, goal, rows, cols) {
+		return HintResult{}, ErrUnsolvable
+	}
+
+	t := loadedTablebase(goal, rows, cols)
+	if t == nil && rows*cols <= maxTableCells {
+		var err error
+		if t, err = distanceTableFor(ctx, goal, rows, cols); err != nil {
+			return HintResult{}, err
+		}
+	}
+	if t != nil {
+		d, ok := t.lookup(board)
+		if !ok {
+			return HintResult{}, ErrUnsolvable
+		}
+		return t.hint(board, d), nil
+	}
+	return searchHint(ctx, board, goal, rows, cols)
+}
+
+// searchHint finds the optimal distance with IDA* and then keeps every first move
+// from which a path of the remaining length still exists.
+func searchHint(ctx context.Context, board, goal []int, rows, cols int) (HintResult, error) {
+	s := &searcher{ctx: ctx, goal: goal, rows: rows, cols: cols}
+	root := newNode(board, rows, cols)
+	found, err := s.run(root)
+	if err != nil {
+		return HintResult{}, err
+	}
+
+	result := HintResult{Distance: found.cost}
+	if found.cost == 0 {
+		return result, nil
+	}
+	for _, child := range root.children() {
+		if _, goalNode := s.search(child, found.cost); goalNode != nil {
+			result.Moves = append(result.Moves, child.blankIdx)
+		}
+		if s.err != nil {
+			return HintResult{}, s.err
+		}
+	}
+	return result, nil
+}
+
+// maxDistanceTables bounds the tablebases Hint keeps for the goals it has seen.
+// A 3x3 table takes 181 kB.
+const maxDistanceTables = 16
+
+// distanceTable is a tablebase Hint builds, or is building, for one goal.
+type distanceTable struct {
+	key  string
+	done chan struct{} // closed once t or err is set
+	t    *Tablebase
+	err  error
+}
+
+// distanceTables caches the tablebases Hint builds for small boards, keyed by tablebaseKey,
+// dropping the least recently used one when it is full.
+var distanceTables = struct {
+	sync.Mutex
+	entries map[string]*list.Element // values are *distanceTable
+	recent  *list.List               // front is the most recently used
+}{entries: make(map[string]*list.Element), recent: list.New()}
+
+// distanceTableFor returns the cached tablebase for goal, building it on first use.
+// The table is built without holding the cache lock, once however many callers ask
+// for it; each caller waits only until its own ctx is done. The board must have at
+// most maxTableCells cells.
+func distanceTableFor(ctx context.Context, goal []int, rows, cols int) (*Tablebase, error) {
+	key := tablebaseKey(goal, rows, cols)
+	for {
+		e, build := acquireDistanceTable(key)
+		if build {
+			e.t, e.err = BuildTablebaseContext(ctx, goal, rows, cols)
+			if e.err != nil {
+				dropDistanceTable(e)
+			}
+			close(e.done)
+		}
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if e.err == nil {
+			return e.t, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !errors.Is(e.err, context.Canceled) && !errors.Is(e.err, context.DeadlineExceeded) {
+			return nil, e.err
+		}
+		// The caller building the table gave up before finishing it; build it again.
+	}
+}
+
+// acquireDistanceTable returns the cache entry for key, adding one when there is none,
+// in which case build is true and the caller must fill it in.
+func acquireDistanceTable(key string) (e *distanceTable, build bool) {
+	distanceTables.Lock()
+	defer distanceTables.Unlock()
+	if el, ok := distanceTables.entries[key]; ok {
+		distanceTables.recent.MoveToFront(el)
+		return el.Value.(*distanceTable), false
+	}
+	e = &distanceTable{key: key, done: make(chan struct{})}
+	distanceTables.entries[key] = distanceTables.recent.PushFront(e)
+	if distanceTables.recent.Len() > maxDistanceTables {
+		oldest := distanceTables.recent.Back()
+		distanceTables.recent.Remove(oldest)
+		delete(distanceTables.entries, oldest.Value.(*distanceTable).key)
+	}
+	return e, true
+}
+
+// dropDistanceTable removes e from the cache unless it was evicted already.
+func dropDistanceTable(e *distanceTable) {
+	distanceTables.Lock()
+	defer distanceTables.Unlock()
+	if el, ok := distanceTables.entries[e.key]; ok && el.Value == e {
+		distanceTables.recent.Remove(el)
+		delete(distanceTables.entries, e.key)
+	}
+}
@@ -0,0 +1,160 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHint(t *testing.T) {
+	tests := []struct {
+		name         string
+		board        []int
+		rows         int
+		cols         int
+		wantDistance int
+		wantMoves    []int
+		wantErr      error
+	}{
+		{"solved", []int{1, 2, 3, 4}, 2, 2, 0, nil, nil},
+		{"2x2 one move", []int{1, 2, 4, 3}, 2, 2, 1, []int{3}, nil},
+		{"2x2 unsolvable", []int{2, 1, 3, 4}, 2, 2, 0, nil, ErrUnsolvable},
+		{"invalid", []int{1, 2, 3}, 2, 2, 0, nil, ErrSizeMismatch},
+		// 1 2 3
+		// 4 9 5
+		// 7 8 6: only moving the blank right keeps a shortest path.
+		{"3x3 single choice", []int{1, 2, 3, 4, 9, 5, 7, 8, 6}, 3, 3, 2, []int{5}, nil},
+		// 1 2 3
+		// 4 9 8
+		// 7 6 5: two optimal solutions diverge at the first move.
+		{"3x3 two choices", []int{1, 2, 3, 4, 9, 8, 7, 6, 5}, 3, 3, 6, []int{5, 7}, nil},
+		// Boards above the table size use IDA*.
+		{"4x4 search", []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 16, 14, 15}, 4, 4, 2, []int{14}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Hint(tt.board, StandardGoal(tt.rows, tt.cols), tt.rows, tt.cols)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Hint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			slices.Sort(got.Moves)
+			if got.Distance != tt.wantDistance || !reflect.DeepEqual(got.Moves, tt.wantMoves) {
+				t.Errorf("Hint() = %+v, want distance %d moves %v", got, tt.wantDistance, tt.wantMoves)
+			}
+		})
+	}
+}
+
+func TestHint_TableMatchesSearch(t *testing.T) {
+	goal := StandardGoal(2, 3)
+	table, err := BuildTablebase(goal, 2, 3)
+	if err != nil {
+		t.Fatalf("BuildTablebase() error = %v", err)
+	}
+	if table.States() != 360 {
+		t.Fatalf("2x3 table has %d states, want 360", table.States())
+	}
+	for _, board := range [][]int{
+		{4, 1, 3, 6, 2, 5},
+		{6, 5, 4, 3, 2, 1},
+		{2, 3, 6, 1, 4, 5},
+	} {
+		want, err := searchHint(context.Background(), board, goal, 2, 3)
+		if err != nil {
+			t.Fatalf("searchHint(%v) error = %v", board, err)
+		}
+		d, _ := table.Distance(board)
+		got := table.hint(board, d)
+		slices.Sort(got.Moves)
+		slices.Sort(want.Moves)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("table hint(%v) = %+v, search = %+v", board, got, want)
+		}
+	}
+}
+
+func TestHintContext_Timeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	// A hard 4x4 instance that cannot be solved within a millisecond.
+	board := []int{16, 12, 9, 13, 15, 11, 10, 14, 3, 7, 2, 5, 4, 8, 6, 1}
+	if _, err := HintContext(ctx, board, StandardGoal(4, 4), 4, 4); err != context.DeadlineExceeded {
+		t.Errorf("HintContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestDistanceTableFor(t *testing.T) {
+	defer resetDistanceTables()
+	resetDistanceTables()
+
+	// Concurrent callers share one table for a goal.
+	goal := StandardGoal(2, 3)
+	tables := make([]*Tablebase, 8)
+	var wg sync.WaitGroup
+	for i := range tables {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tb, err := distanceTableFor(context.Background(), goal, 2, 3)
+			if err != nil {
+				t.Errorf("distanceTableFor() error = %v", err)
+			}
+			tables[i] = tb
+		}()
+	}
+	wg.Wait()
+	for _, tb := range tables[1:] {
+		if tb == nil || tb != tables[0] {
+			t.Fatalf("distanceTableFor() built %p and %p for the same goal", tables[0], tb)
+		}
+	}
+
+	// The cache keeps only the most recently used goals.
+	for rank := range maxDistanceTables + 4 {
+		g := unrankPartialPermutation(rank, 6, 6)
+		for i := range g {
+			g[i]++
+		}
+		if _, err := distanceTableFor(context.Background(), g, 2, 3); err != nil {
+			t.Fatalf("distanceTableFor(%v) error = %v", g, err)
+		}
+	}
+	distanceTables.Lock()
+	n, m := len(distanceTables.entries), distanceTables.recent.Len()
+	distanceTables.Unlock()
+	if n != maxDistanceTables || m != maxDistanceTables {
+		t.Errorf("cache holds %d entries and %d list elements, want %d", n, m, maxDistanceTables)
+	}
+}
+
+func TestHintContext_CancelledBuild(t *testing.T) {
+	defer resetDistanceTables()
+	resetDistanceTables()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	goal := StandardGoal(3, 3)
+	if _, err := HintContext(ctx, []int{1, 2, 3, 4, 5, 6, 7, 9, 8}, goal, 3, 3); !errors.Is(err, context.Canceled) {
+		t.Fatalf("HintContext() error = %v, want %v", err, context.Canceled)
+	}
+	// The abandoned build is not cached, so the next caller builds the table.
+	got, err := HintContext(context.Background(), []int{1, 2, 3, 4, 5, 6, 7, 9, 8}, goal, 3, 3)
+	if err != nil || got.Distance != 1 {
+		t.Errorf("HintContext() = %+v, %v; want distance 1", got, err)
+	}
+}
+
+// resetDistanceTables empties the cache of tables built by Hint.
+func resetDistanceTables() {
+	distanceTables.Lock()
+	defer distanceTables.Unlock()
+	clear(distanceTables.entries)
+	distanceTables.recent.Init()
+}
@@ -0,0 +1,121 @@
+package solver
+
+import "context"
+
+// LockedSolvable reports whether start can reach goal on a board whose locked cells,
+// walls or bolted tiles, never move. Unlike the parity test of CheckSolvability, it is
+// exact for any mask, including masks that split the free cells or leave regions that
+// are not 2-connected; see SolveOptions.Locked.
+//
+// Example:
+//
+//	// The free cells of a 3x3 board with its center locked form a ring, which can only
+//	// rotate its tiles.
+//	locked := []bool{false, false, false, false, true, false, false, false, false}
+//	ok, err := LockedSolvable([]int{2, 3, 6, 1, 5, 9, 4, 7, 8}, StandardGoal(3, 3), locked, 3, 3) // true
+func LockedSolvable(start, goal []int, locked []bool, rows, cols int) (bool, error) {
+	if err := validate(start, rows, cols); err != nil {
+		return false, err
+	}
+	if err := validate(goal, rows, cols); err != nil {
+		return false, err
+	}
+	if err := validateLocked(start, goal, locked); err != nil {
+		return false, err
+	}
+	return gridGraph(rows, cols, locked).solvable(start, goal), nil
+}
+
+// solveVariant is SolveWithOptions for boards with opts.Locked or opts.Wrap. Tablebases
+// and opts.Cache are not consulted, since they describe ordinary boards.
+func solveVariant(ctx context.Context, start, goal []int, rows, cols int, opts SolveOptions) ([]int, error) {
+	if opts.Locked != nil {
+		if err := validateLocked(start, goal, opts.Locked); err != nil {
+			return nil, err
+		}
+	}
+	g := wrappedGrid(rows, cols, opts.Wrap, opts.Locked)
+	if !g.solvable(start, goal) {
+		return nil, ErrUnsolvable
+	}
+	t := newGraphGoal(goal, g)
+	t.rows, t.cols, t.wrap = rows, cols, opts.Wrap
+	root := newNode(start, rows, cols)
+	root.locked = opts.Locked
+	root.wrap = opts.Wrap
+	return solveTarget(ctx, root, t, opts)
+}
+
+// solveGraph finds a shortest path from start to goal for a puzzle whose blank moves along
+// the edges of g, using the estimates of t. The path holds vertices of g.
+func solveGraph(ctx context.Context, start, goal []int, g *graph, t *graphGoal, opts SolveOptions) ([]int, error) {
+	if !g.solvable(start, goal) {
+		return nil, ErrUnsolvable
+	}
+	root := newNode(start, 0, 0)
+	root.adj = g.adj
+	return solveTarget(ctx, root, t, opts)
+}
+
+// solveTarget runs IDA* from root towards t and returns the path of the blank, filling in
+// opts.Stats.
+func solveTarget(ctx context.Context, root *node, t target, opts SolveOptions) ([]int, error) {
+	s := &searcher{ctx: ctx, progress: opts.Progress, target: t}
+	found, err := s.run(root)
+	if opts.Stats != nil {
+		*opts.Stats = Stats{NodesExpanded: s.expanded, Iterations: s.iterations}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return found.path(), nil
+}
+
+// graphGoal is a complete goal whose tiles move along a graph other than the full grid.
+type graphGoal struct {
+	goal    []int
+	goalPos []int   // goalPos[v] is the goal cell of tile v
+	dist    [][]int // shortest-path distances between cells
+	// rows and cols, when set, make the graph part of a rows x cols grid whose moves
+	// wrap as given by wrap, so that Manhattan distance with linear conflict is a second
+	// estimate.
+	rows int
+	cols int
+	wrap Wrap
+}
+
+func newGraphGoal(goal []int, g *graph) *graphGoal {
+	t := &graphGoal{goal: goal, goalPos: make([]int, len(goal)+1), dist: g.distances()}
+	for i, v := range goal {
+		t.goalPos[v] = i
+	}
+	return t
+}
+
+// heuristic is the sum of the shortest-path distances of the tiles to their goal cells.
+// On part of a grid it takes the larger of that and Manhattan distance with linear
+// conflict, measured around the wrapping edges, which stays a lower bound when moves
+// are taken away.
+func (t *graphGoal) heuristic(board []int) int {
+	h := 0
+	blank := len(board)
+	for i, v := range board {
+		// Solvable boards only hold tiles in components that reach their goal cells.
+		if d := t.dist[i][t.goalPos[v]]; v != blank && d > 0 {
+			h += d
+		}
+	}
+	if t.rows == 0 {
+		return h
+	}
+	return max(h, wrappedHeuristic(board, t.goal, t.rows, t.cols, t.wrap))
+}
+
+func (t *graphGoal) matches(board []int) bool {
+	for i, v := range board {
+		if v != t.goal[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// lockedMask returns a mask of n cells with the given cells locked.
+func lockedMask(n int, cells ...int) []bool {
+	locked := make([]bool, n)
+	for _, c := range cells {
+		locked[c] = true
+	}
+	return locked
+}
+
+func TestSolveWithOptions_Locked(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   []int
+		goal    []int
+		rows    int
+		cols    int
+		locked  []bool
+		wantErr error
+	}{
+		// The locked center forces tiles around the ring.
+		{"ring", []int{2, 3, 6, 1, 5, 9, 4, 7, 8}, StandardGoal(3, 3), 3, 3, lockedMask(9, 4), nil},
+		// Solvable by parity, but a ring can only rotate its tiles.
+		{"ring unsolvable", []int{2, 1, 3, 4, 5, 6, 7, 8, 9}, StandardGoal(3, 3), 3, 3, lockedMask(9, 4), ErrUnsolvable},
+		{"wall around the corner", []int{4, 1, 3, 7, 2, 6, 5, 8, 9}, StandardGoal(3, 3), 3, 3, lockedMask(9, 2), nil},
+		{"bolted tiles", []int{1, 2, 3, 4, 5, 6, 7, 8, 13, 9, 11, 12, 10, 14, 15, 16}, StandardGoal(4, 4), 4, 4, lockedMask(16, 5, 6), nil},
+		{"no moves needed", []int{1, 2, 3, 4}, StandardGoal(2, 2), 2, 2, lockedMask(4, 0), nil},
+		{"mask length", []int{1, 2, 3, 4}, StandardGoal(2, 2), 2, 2, lockedMask(3), ErrLockedMask},
+		{"locked tile differs", []int{2, 1, 3, 4}, StandardGoal(2, 2), 2, 2, lockedMask(4, 0), ErrLockedTile},
+		{"locked blank", []int{1, 2, 4, 3}, StandardGoal(2, 2), 2, 2, lockedMask(4, 3), ErrLockedBlank},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := SolveWithOptions(context.Background(), tt.start, tt.goal, tt.rows, tt.cols, SolveOptions{Locked: tt.locked})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SolveWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := playPath(tt.start, path); !slices.Equal(got, tt.goal) {
+				t.Fatalf("path %v ends at %v", path, got)
+			}
+			for _, c := range path {
+				if tt.locked[c] {
+					t.Fatalf("path %v moves the blank into locked cell %d", path, c)
+				}
+			}
+			g := gridGraph(tt.rows, tt.cols, tt.locked)
+			if want := lockedDistance(g, tt.start, tt.goal); len(path)-1 != want {
+				t.Errorf("path takes %d moves, want %d", len(path)-1, want)
+			}
+		})
+	}
+}
+
+// lockedDistance returns the number of moves from start to goal along g by breadth-first search.
+func lockedDistance(g *graph, start, goal []int) int {
+	seen := map[string]bool{boardKey(start): true}
+	frontier := [][]int{start}
+	for d := 0; len(frontier) > 0; d++ {
+		var next [][]int
+		for _, b := range frontier {
+			if slices.Equal(b, goal) {
+				return d
+			}
+			blank := slices.Index(b, len(b))
+			for _, w := range g.adj[blank] {
+				child := slices.Clone(b)
+				child[blank], child[w] = child[w], child[blank]
+				if key := boardKey(child); !seen[key] {
+					seen[key] = true
+					next = append(next, child)
+				}
+			}
+		}
+		frontier = next
+	}
+	return -1
+}
+
+func TestLockedSolvable(t *testing.T) {
+	tests := []struct {
+		name   string
+		start  []int
+		locked []bool
+		want   bool
+	}{
+		{"ring rotation", []int{2, 3, 6, 1, 5, 9, 4, 7, 8}, lockedMask(9, 4), true},
+		{"ring swap", []int{2, 1, 3, 4, 5, 6, 7, 8, 9}, lockedMask(9, 4), false},
+		// With the middle row locked, the top row cannot change at all.
+		{"cut off row", []int{2, 1, 3, 4, 5, 6, 7, 9, 8}, lockedMask(9, 3, 4, 5), false},
+		{"cut off row in place", []int{1, 2, 3, 4, 5, 6, 7, 9, 8}, lockedMask(9, 3, 4, 5), true},
+		{"parity still decides 2-connected regions", []int{2, 1, 3, 4, 5, 6, 7, 8, 9}, lockedMask(9, 2), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LockedSolvable(tt.start, StandardGoal(3, 3), tt.locked, 3, 3)
+			if err != nil || got != tt.want {
+				t.Errorf("LockedSolvable() = %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGraphGoal_Heuristic(t *testing.T) {
+	// Tile 2 has to go around the locked center: 4 moves instead of a Manhattan distance of 2.
+	locked := lockedMask(9, 4)
+	goal := StandardGoal(3, 3)
+	target := newGraphGoal(goal, gridGraph(3, 3, locked))
+	target.rows, target.cols = 3, 3
+	board := []int{1, 8, 3, 4, 5, 6, 7, 2, 9}
+	if h := target.heuristic(board); h != 8 {
+		t.Errorf("heuristic(%v) = %d, want 8", board, h)
+	}
+	if !target.matches(goal) || target.matches(board) {
+		t.Error("matches() does not recognize exactly the goal")
+	}
+}
@@ -1,5 +1,7 @@
 package solver
 
+import "math"
+
 // inversionNumber calculates the number of inversions in the puzzle configuration.
 // It counts pairs of tiles (i, j) such that i < j and input[i] > input[j].
 // Note: This implementation treats the blank tile as the largest number.
@@ -170,3 +172,62 @@ func calculateHeuristic(board, goal []int, rows, cols int) int {
 	return boardManhattanDistance(board, goal, rows, cols) +
 		linearConflict(board, goal, rows, cols)
 }
+
+// minCostMatching returns the smallest total cost of assigning each row of the square
+// matrix cost to a different column, using the Hungarian algorithm in O(n^3).
+//
+// Example:
+//
+//	minCostMatching([][]int{{4, 1}, {2, 3}}) // returns 3
+func minCostMatching(cost [][]int) int {
+	n := len(cost)
+	// Potentials u of rows and v of columns keep reduced costs non-negative; match[j] is
+	// the row assigned to column j, counting from 1, and column 0 is a sentinel.
+	u, v := make([]int, n+1), make([]int, n+1)
+	match, way := make([]int, n+1), make([]int, n+1)
+	for i := 1; i <= n; i++ {
+		match[0] = i
+		j0 := 0
+		minv := make([]int, n+1)
+		for j := range minv {
+			minv[j] = math.MaxInt
+		}
+		used := make([]bool, n+1)
+		for match[j0] != 0 {
+			used[j0] = true
+			i0, delta, j1 := match[j0], math.MaxInt, 0
+			for j := 1; j <= n; j++ {
+				if used[j] {
+					continue
+				}
+				if c := cost[i0-1][j-1] - u[i0] - v[j]; c < minv[j] {
+					minv[j], way[j] = c, j0
+				}
+				if minv[j] < delta {
+					delta, j1 = minv[j], j
+				}
+			}
+			for j := 0; j <= n; j++ {
+				if used[j] {
+					u[match[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+		}
+		// Flip the augmenting path back to column 0.
+		for j0 != 0 {
+			j1 := way[j0]
+			match[j0] = match[j1]
+			j0 = j1
+		}
+	}
+
+	total := 0
+	for j := 1; j <= n; j++ {
+		total += cost[match[j]-1][j-1]
+	}
+	return total
+}
@@ -1,6 +1,9 @@
 package solver
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestInversionNumber(t *testing.T) {
 	tests := []struct {
@@ -117,3 +120,46 @@ func TestCalculateHeuristic(t *testing.T) {
 		})
 	}
 }
+
+func TestMinCostMatching(t *testing.T) {
+	tests := []struct {
+		name string
+		cost [][]int
+		want int
+	}{
+		{"empty", nil, 0},
+		{"single", [][]int{{7}}, 7},
+		{"crossed", [][]int{{4, 1}, {2, 3}}, 3},
+		{"3x3", [][]int{{4, 1, 3}, {2, 0, 5}, {3, 2, 2}}, 5},
+		{"ties", [][]int{{1, 1, 1}, {1, 1, 1}, {1, 1, 1}}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := minCostMatching(tt.cost); got != tt.want {
+				t.Errorf("minCostMatching() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinCostMatching_BruteForce(t *testing.T) {
+	const n = 6
+	cost := make([][]int, n)
+	for i := range cost {
+		cost[i] = make([]int, n)
+		for j := range cost[i] {
+			cost[i][j] = (i*7 + j*13 + i*j*5) % 11
+		}
+	}
+	want := math.MaxInt
+	for r := 0; r < factorial(n); r++ {
+		total := 0
+		for i, j := range unrankPartialPermutation(r, n, n) {
+			total += cost[i][j]
+		}
+		want = min(want, total)
+	}
+	if got := minCostMatching(cost); got != want {
+		t.Errorf("minCostMatching() = %d, want %d", got, want)
+	}
+}
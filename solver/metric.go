@@ -0,0 +1,137 @@
+package solver
+
+import (
+	"context"
+	"slices"
+)
+
+// Metric selects what counts as one move.
+type Metric int
+
+const (
+	// SingleTile counts every tile slid by one cell as a move.
+	SingleTile Metric = iota
+	// MultiTile counts sliding several tiles of a row or column at once, by one cell
+	// towards the blank, as a single move, as in many physical puzzles and speed-solving
+	// rules.
+	MultiTile
+)
+
+// String returns the usual abbreviation of the metric: "stm" or "mtm".
+func (m Metric) String() string {
+	switch m {
+	case SingleTile:
+		return "stm"
+	case MultiTile:
+		return "mtm"
+	default:
+		return "unknown"
+	}
+}
+
+// Segments groups a path of blank indices, as returned by SolveWithOptions, into the
+// moves of the multi-tile metric: runs of steps in the same direction, each listing the
+// cells the blank visits, from where it starts. A run never takes the blank back to where
+// it started, which would need the blank to go all the way around a wrapping line.
+// len(Segments(path, cols)) is the length of the path in the multi-tile metric.
+//
+// Example:
+//
+//	// On a 3x3 board, the blank slides left twice and then up.
+//	Segments([]int{8, 7, 6, 3}, 3) // returns [][]int{{8, 7, 6}, {6, 3}}
+func Segments(path []int, cols int) [][]int {
+	var segments [][]int
+	for i := 1; i < len(path); i++ {
+		if i > 1 {
+			last := segments[len(segments)-1]
+			if stepDirection(path[i-2], path[i-1], cols) == stepDirection(path[i-1], path[i], cols) && path[i] != last[0] {
+				segments[len(segments)-1] = append(last, path[i])
+				continue
+			}
+		}
+		segments = append(segments, []int{path[i-1], path[i]})
+	}
+	return segments
+}
+
+// stepDirection returns the direction in which the blank moves from cell from to the
+// neighboring cell to. A blank that jumps to the opposite edge of its row or column has
+// wrapped around.
+func stepDirection(from, to, cols int) int {
+	switch fc, tc := from%cols, to%cols; {
+	case fc != tc && (tc == fc+1 || tc < fc-1):
+		return right
+	case fc != tc:
+		return left
+	case to == from+cols || to < from-cols:
+		return down
+	default:
+		return up
+	}
+}
+
+// isHorizontal reports whether dir is left or right.
+func isHorizontal(dir int) bool {
+	return dir == left || dir == right
+}
+
+// solveMultiTile is SolveWithOptions for the multi-tile metric. Tablebases and
+// opts.Cache are not consulted, since they hold single-tile solutions.
+func solveMultiTile(ctx context.Context, start, goal []int, rows, cols int, opts SolveOptions) ([]int, error) {
+	if opts.Locked != nil {
+		if err := validateLocked(start, goal, opts.Locked); err != nil {
+			return nil, err
+		}
+	}
+	if !wrappedGrid(rows, cols, opts.Wrap, opts.Locked).solvable(start, goal) {
+		return nil, ErrUnsolvable
+	}
+	root := newNode(start, rows, cols)
+	root.locked = opts.Locked
+	root.wrap = opts.Wrap
+	root.metric = MultiTile
+	return solveTarget(ctx, root, &multiTileGoal{goal: goal, rows: rows, cols: cols, wrap: opts.Wrap}, opts)
+}
+
+// multiTileGoal is a complete goal reached by moves of the multi-tile metric.
+type multiTileGoal struct {
+	goal []int
+	rows int
+	cols int
+	wrap Wrap
+}
+
+// heuristic is a lower bound on the moves of the multi-tile metric. A horizontal move
+// slides at most cols-1 tiles by one column, and a vertical move at most rows-1 tiles by
+// one row, so the columns and rows the tiles still have to travel need at least h
+// horizontal and v vertical moves. Two moves along a line that does not wrap make one
+// move or none, so they alternate in a shortest solution: without vertical wrapping,
+// there is a horizontal move between every two vertical ones, and the other way around.
+func (t *multiTileGoal) heuristic(board []int) int {
+	horizontal, vertical := t.wrap.horizontal(t.cols), t.wrap.vertical(t.rows)
+	goalPos := make([]int, len(board)+1)
+	for i, v := range t.goal {
+		goalPos[v] = i
+	}
+	across, along := 0, 0
+	for i, v := range board {
+		if v != len(board) {
+			across += wrappedDistance(i%t.cols, goalPos[v]%t.cols, t.cols, horizontal)
+			along += wrappedDistance(i/t.cols, goalPos[v]/t.cols, t.rows, vertical)
+		}
+	}
+	h := (across + t.cols - 2) / (t.cols - 1)
+	v := (along + t.rows - 2) / (t.rows - 1)
+	bound := h + v
+	if !horizontal {
+		bound = max(bound, 2*h-1)
+	}
+	if !vertical {
+		bound = max(bound, 2*v-1)
+	}
+	return bound
+}
+
+func (t *multiTileGoal) matches(board []int) bool {
+	return slices.Equal(board, t.goal)
+}
@@ -0,0 +1,167 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestMetric_String(t *testing.T) {
+	for m, want := range map[Metric]string{SingleTile: "stm", MultiTile: "mtm", Metric(5): "unknown"} {
+		if got := m.String(); got != want {
+			t.Errorf("Metric(%d).String() = %q, want %q", int(m), got, want)
+		}
+	}
+}
+
+func TestSegments(t *testing.T) {
+	tests := []struct {
+		name string
+		path []int
+		cols int
+		want [][]int
+	}{
+		{"no moves", []int{8}, 3, nil},
+		{"row then column", []int{8, 7, 6, 3}, 3, [][]int{{8, 7, 6}, {6, 3}}},
+		{"turns", []int{8, 5, 4, 1, 0}, 3, [][]int{{8, 5}, {5, 4}, {4, 1}, {1, 0}}},
+		{"reversal", []int{8, 7, 8}, 3, [][]int{{8, 7}, {7, 8}}},
+		// On a cylinder, the blank slides left across the edge.
+		{"across the edge", []int{3, 5, 4}, 3, [][]int{{3, 5, 4}}},
+		// Going all the way around a row of 3 takes two moves.
+		{"around the row", []int{3, 5, 4, 3}, 3, [][]int{{3, 5, 4}, {4, 3}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Segments(tt.path, tt.cols); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Segments() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// multiTileDistances returns the distance in the multi-tile metric from goal to every
+// board reachable by breadth-first search, whose moves are reversible.
+func multiTileDistances(goal []int, rows, cols int, wrap Wrap, locked []bool) map[string]int {
+	dist := map[string]int{boardKey(goal): 0}
+	queue := [][]int{goal}
+	for len(queue) > 0 {
+		b := queue[0]
+		queue = queue[1:]
+		n := newNode(b, rows, cols)
+		n.wrap, n.locked, n.metric = wrap, locked, MultiTile
+		for _, c := range n.children() {
+			if key := boardKey(c.board); !containsDistance(dist, key) {
+				dist[key] = dist[boardKey(b)] + 1
+				queue = append(queue, c.board)
+			}
+		}
+	}
+	return dist
+}
+
+// containsDistance reports whether dist has an entry for key.
+func containsDistance(dist map[string]int, key string) bool {
+	_, ok := dist[key]
+	return ok
+}
+
+func TestMultiTileHeuristic_Admissible(t *testing.T) {
+	tests := []struct {
+		name      string
+		rows      int
+		cols      int
+		wrap      Wrap
+		locked    []bool
+		reachable int
+	}{
+		{"2x3", 2, 3, NoWrap, nil, 360},
+		{"3x3", 3, 3, NoWrap, nil, 181440},
+		{"2x4 cylinder", 2, 4, Cylinder, nil, 20160},
+		{"4x2 torus", 4, 2, Torus, nil, 20160},
+		{"3x3 with a locked corner", 3, 3, NoWrap, lockedMask(9, 0), 20160},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goal := StandardGoal(tt.rows, tt.cols)
+			dist := multiTileDistances(goal, tt.rows, tt.cols, tt.wrap, tt.locked)
+			if len(dist) != tt.reachable {
+				t.Fatalf("search reaches %d boards, want %d", len(dist), tt.reachable)
+			}
+			target := &multiTileGoal{goal: goal, rows: tt.rows, cols: tt.cols, wrap: tt.wrap}
+			board := make([]int, len(goal))
+			for key, d := range dist {
+				for i := range board {
+					board[i] = int(key[2*i])<<8 | int(key[2*i+1])
+				}
+				if h := target.heuristic(board); h > d {
+					t.Fatalf("heuristic(%v) = %d, but the board is %d moves from the goal", board, h, d)
+				}
+			}
+		})
+	}
+}
+
+func TestSolveWithOptions_MultiTile(t *testing.T) {
+	tests := []struct {
+		name      string
+		start     []int
+		rows      int
+		cols      int
+		wrap      Wrap
+		locked    []bool
+		wantMoves int
+		wantErr   error
+	}{
+		{"solved", []int{1, 2, 3, 4, 5, 6, 7, 8, 9}, 3, 3, NoWrap, nil, 0, nil},
+		// Tiles 7 and 8 slide right together.
+		{"one row segment", []int{1, 2, 3, 4, 5, 6, 9, 7, 8}, 3, 3, NoWrap, nil, 1, nil},
+		{"3x3", []int{8, 6, 7, 2, 5, 4, 3, 9, 1}, 3, 3, NoWrap, nil, -1, nil},
+		{"3x3 scrambled", []int{6, 4, 7, 8, 5, 9, 3, 2, 1}, 3, 3, NoWrap, nil, -1, nil},
+		{"2x4", []int{8, 7, 1, 5, 2, 3, 4, 6}, 2, 4, NoWrap, nil, -1, nil},
+		{"2x4 cylinder", []int{8, 7, 1, 5, 2, 3, 4, 6}, 2, 4, Cylinder, nil, -1, nil},
+		{"4x2 torus", []int{7, 1, 3, 4, 5, 6, 8, 2}, 4, 2, Torus, nil, -1, nil},
+		{"locked corner", []int{1, 3, 6, 4, 2, 9, 7, 5, 8}, 3, 3, NoWrap, lockedMask(9, 0), -1, nil},
+		{"swap", []int{2, 1, 3, 4, 5, 6, 7, 8, 9}, 3, 3, NoWrap, nil, 0, ErrUnsolvable},
+		{"locked blank", []int{9, 2, 3, 4, 5, 6, 7, 8, 1}, 3, 3, NoWrap, lockedMask(9, 0), 0, ErrLockedBlank},
+	}
+	distances := map[string]map[string]int{} // by board and rules, as they take a while
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goal := StandardGoal(tt.rows, tt.cols)
+			var stats Stats
+			path, err := SolveWithOptions(context.Background(), tt.start, goal, tt.rows, tt.cols, SolveOptions{Stats: &stats, Wrap: tt.wrap, Locked: tt.locked, Metric: MultiTile})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SolveWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			g := wrappedGrid(tt.rows, tt.cols, tt.wrap, tt.locked)
+			for i := 1; i < len(path); i++ {
+				if !slices.Contains(g.adj[path[i-1]], path[i]) {
+					t.Fatalf("path %v moves the blank from %d to %d, which are not neighbors", path, path[i-1], path[i])
+				}
+			}
+			if got := playPath(tt.start, path); !slices.Equal(got, goal) {
+				t.Fatalf("path %v ends at %v", path, got)
+			}
+			want := tt.wantMoves
+			if want == -1 {
+				rules := fmt.Sprint(tt.rows, tt.cols, tt.wrap, tt.locked)
+				if distances[rules] == nil {
+					distances[rules] = multiTileDistances(goal, tt.rows, tt.cols, tt.wrap, tt.locked)
+				}
+				want = distances[rules][boardKey(tt.start)]
+			}
+			if got := len(Segments(path, tt.cols)); got != want {
+				t.Errorf("path %v takes %d multi-tile moves, want %d", path, got, want)
+			}
+			if stats.NodesExpanded == 0 && want > 0 {
+				t.Error("Stats were not filled in")
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var ErrLabelMismatch = errors.New("start and goal hold different tiles")
+
+// ValidateMultiset checks start and goal boards of a rows x cols puzzle whose tiles may
+// share labels, as in color-sorting puzzles. Each board holds numbers from 1 to
+// rows*cols, with rows*cols, the blank, exactly once; the other numbers label tiles and
+// may repeat, as long as both boards hold the same tiles. Element errors are returned as
+// *ValidationError, different tiles as ErrLabelMismatch.
+//
+// Example:
+//
+//	// Three red tiles (1), three blue tiles (2) and two green tiles (3).
+//	ValidateMultiset([]int{2, 1, 2, 1, 9, 1, 3, 2, 3}, []int{1, 1, 1, 2, 2, 2, 3, 3, 9}, 3, 3) // returns nil
+func ValidateMultiset(start, goal []int, rows, cols int) error {
+	for _, board := range [][]int{start, goal} {
+		if err := validateSize(board, rows, cols); err != nil {
+			return err
+		}
+		if err := validateLabels(board); err != nil {
+			return err
+		}
+	}
+	inStart, inGoal := make([]int, len(start)+1), make([]int, len(goal)+1)
+	for i := range start {
+		inStart[start[i]]++
+		inGoal[goal[i]]++
+	}
+	for v := range inStart {
+		if inStart[v] != inGoal[v] {
+			return fmt.Errorf("%w: tile %d appears %d times in the start and %d times in the goal", ErrLabelMismatch, v, inStart[v], inGoal[v])
+		}
+	}
+	return nil
+}
+
+// validateLabels checks that board holds numbers from 1 to len(board) with the blank,
+// len(board), exactly once, returning a *ValidationError wrapping ErrInvalidElement
+// otherwise.
+func validateLabels(board []int) error {
+	blank := len(board)
+	e := &ValidationError{Err: ErrInvalidElement, ExpectedLength: len(board), ActualLength: len(board)}
+	var blanks []int
+	for i, v := range board {
+		switch {
+		case v < 1 || v > blank:
+			e.OutOfRange = append(e.OutOfRange, Cell{Index: i, Value: v})
+		case v == blank:
+			blanks = append(blanks, i)
+		}
+	}
+	switch len(blanks) {
+	case 0:
+		e.Missing = []int{blank}
+	case 1:
+	default:
+		e.Duplicates = []Duplicate{{Value: blank, Positions: blanks}}
+	}
+	if len(e.OutOfRange) > 0 || len(e.Missing) > 0 || len(e.Duplicates) > 0 {
+		return e
+	}
+	return nil
+}
+
+// MultisetSolvable reports whether start can reach goal on a rows x cols board whose
+// tiles may share labels; see ValidateMultiset. Tiles with the same label are
+// interchangeable, so the puzzle is solvable when some matching of the start's tiles to
+// the goal's cells of the same label is. On an ordinary board, a repeated label makes
+// every arrangement solvable, since swapping the two tiles in the matching fixes the
+// parity; otherwise the parity rule of CheckSolvability applies.
+//
+// Example:
+//
+//	// Swapping two tiles is impossible with distinct labels, but not when others match.
+//	MultisetSolvable([]int{2, 1, 3, 3, 9, 3, 3, 3, 3}, []int{1, 2, 3, 3, 3, 3, 3, 3, 9}, 3, 3) // true
+func MultisetSolvable(start, goal []int, rows, cols int) (bool, error) {
+	if err := ValidateMultiset(start, goal, rows, cols); err != nil {
+		return false, err
+	}
+	return gridGraph(rows, cols, nil).multisetSolvable(start, goal), nil
+}
+
+// SolveMultiset finds a shortest solution of a puzzle whose tiles may share labels; see
+// ValidateMultiset. Tiles with the same label are interchangeable: the search ends on any
+// board with the goal's labels. The heuristic assigns the tiles of each label to the
+// goal cells of that label at the smallest total distance, a minimum-cost matching.
+//
+// The path holds blank indices as for Solve. opts.Locked, opts.Wrap, opts.Stats and
+// opts.Progress work as for SolveWithOptions; tablebases and opts.Cache are not consulted,
+// and the MultiTile metric returns ErrUnsupportedOption.
+//
+// Example:
+//
+//	path, err := SolveMultiset(ctx, []int{2, 1, 2, 1, 9, 1, 3, 2, 3}, []int{1, 1, 1, 2, 2, 2, 3, 3, 9}, 3, 3, SolveOptions{})
+func SolveMultiset(ctx context.Context, start, goal []int, rows, cols int, opts SolveOptions) ([]int, error) {
+	if err := ValidateMultiset(start, goal, rows, cols); err != nil {
+		return nil, err
+	}
+	if opts.Metric != SingleTile {
+		return nil, fmt.Errorf("%w: repeated labels in the %v metric", ErrUnsupportedOption, opts.Metric)
+	}
+	if opts.Locked != nil {
+		if err := validateLocked(start, goal, opts.Locked); err != nil {
+			return nil, err
+		}
+	}
+	g := wrappedGrid(rows, cols, opts.Wrap, opts.Locked)
+	if !g.multisetSolvable(start, goal) {
+		return nil, ErrUnsolvable
+	}
+	root := newNode(start, rows, cols)
+	root.locked = opts.Locked
+	root.wrap = opts.Wrap
+	return solveTarget(ctx, root, newMultisetGoal(goal, g), opts)
+}
+
+// multisetSolvable is solvable for boards whose tiles may share labels. The blocks permute
+// disjoint sets of cells, so a matching of the start's tiles to the goal's cells exists
+// exactly when each block can take the labels on its cells to those of the goal.
+func (g *graph) multisetSolvable(start, goal []int) bool {
+	board, r := g.settle(start, goal)
+	if board == nil {
+		return false
+	}
+	for _, b := range g.blocks(r) {
+		if !g.blockMatches(b, board, goal) {
+			return false
+		}
+	}
+	return true
+}
+
+// blockMatches reports whether the tours of block b from its entry can take the labels
+// of board on the block's other vertices to those of goal.
+func (g *graph) blockMatches(b block, board, goal []int) bool {
+	members := b.vertices[1:]
+	have, want := make([]int, len(members)), make([]int, len(members))
+	for i, v := range members {
+		have[i], want[i] = board[v], goal[v]
+	}
+	sorted := slices.Sorted(slices.Values(have))
+	if !slices.Equal(sorted, slices.Sorted(slices.Values(want))) {
+		return false
+	}
+	inBlock := b.vertexSet()
+
+	switch g.kind(b, inBlock) {
+	case cycleBlock:
+		order := g.cycleOrder(b, inBlock)
+		for shift := range order {
+			rotated := true
+			for i, v := range order {
+				if board[v] != goal[order[(i+shift)%len(order)]] {
+					rotated = false
+					break
+				}
+			}
+			if rotated {
+				return true
+			}
+		}
+		return false
+	case bipartiteBlock:
+		// Two tiles with the same label can be matched either way, which fixes the parity.
+		for i := 1; i < len(sorted); i++ {
+			if sorted[i] == sorted[i-1] {
+				return true
+			}
+		}
+		local := make([]int, len(members))
+		for i := range members {
+			local[i] = slices.Index(want, have[i])
+		}
+		return permutationParity(local) == 0
+	case thetaBlock:
+		// Number the labels from 1, leaving 0 for the blank on the entry.
+		ids := map[int]byte{}
+		for _, v := range sorted {
+			if _, ok := ids[v]; !ok {
+				ids[v] = byte(len(ids) + 1)
+			}
+		}
+		start := make([]byte, len(b.vertices))
+		target := make([]byte, len(b.vertices))
+		for i, v := range members {
+			start[i+1], target[i+1] = ids[board[v]], ids[goal[v]]
+		}
+		return g.blockReaches(b, inBlock, start, target)
+	default:
+		return true
+	}
+}
+
+// multisetGoal is a goal whose tiles may share labels.
+type multisetGoal struct {
+	goal  []int
+	cells map[int][]int // the goal cells of each label
+	dist  [][]int       // shortest-path distances between cells
+}
+
+func newMultisetGoal(goal []int, g *graph) *multisetGoal {
+	t := &multisetGoal{goal: goal, cells: map[int][]int{}, dist: g.distances()}
+	for i, v := range goal {
+		if v != len(goal) {
+			t.cells[v] = append(t.cells[v], i)
+		}
+	}
+	return t
+}
+
+// heuristic is the sum, over the labels, of the smallest total distance at which the
+// tiles of the label can be matched to its goal cells. Every move takes one tile one
+// step, and the tiles of a label end up on its goal cells in some order, so this never
+// overestimates.
+func (t *multisetGoal) heuristic(board []int) int {
+	at := make(map[int][]int, len(t.cells))
+	for i, v := range board {
+		if v != len(board) {
+			at[v] = append(at[v], i)
+		}
+	}
+	// Cells in different components cost more than any matching within them, which
+	// boards reachable from a solvable start always have.
+	unreachable := len(board) * len(board)
+	h := 0
+	for v, from := range at {
+		to := t.cells[v]
+		if len(from) == 1 {
+			h += max(t.dist[from[0]][to[0]], 0)
+			continue
+		}
+		cost := make([][]int, len(from))
+		for i, a := range from {
+			cost[i] = make([]int, len(to))
+			for j, b := range to {
+				if cost[i][j] = t.dist[a][b]; cost[i][j] == unreachableDistance {
+					cost[i][j] = unreachable
+				}
+			}
+		}
+		h += minCostMatching(cost)
+	}
+	return h
+}
+
+func (t *multisetGoal) matches(board []int) bool {
+	return slices.Equal(board, t.goal)
+}
@@ -0,0 +1,192 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestValidateMultiset(t *testing.T) {
+	colors := []int{1, 1, 1, 2, 2, 2, 3, 3, 9}
+	tests := []struct {
+		name    string
+		start   []int
+		goal    []int
+		wantErr error
+	}{
+		{"colors", []int{2, 1, 2, 1, 9, 1, 3, 2, 3}, colors, nil},
+		{"permutation", []int{1, 2, 3, 4, 5, 6, 7, 8, 9}, StandardGoal(3, 3), nil},
+		{"no blank", []int{2, 1, 2, 1, 3, 1, 3, 2, 3}, colors, ErrInvalidElement},
+		{"two blanks", []int{2, 1, 2, 9, 9, 1, 3, 2, 3}, colors, ErrInvalidElement},
+		{"out of range", []int{2, 1, 2, 1, 9, 1, 3, 2, 0}, colors, ErrInvalidElement},
+		{"different tiles", []int{2, 1, 2, 1, 9, 1, 3, 2, 2}, colors, ErrLabelMismatch},
+		{"wrong length", []int{1, 2, 3}, colors, ErrSizeMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateMultiset(tt.start, tt.goal, 3, 3); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateMultiset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// checkMultisetSolvable compares g.multisetSolvable against a search for every
+// arrangement of the goal's tiles on the vertices with neighbors, the others staying in
+// place.
+func checkMultisetSolvable(t *testing.T, name string, g *graph, goal []int) {
+	t.Helper()
+	reachable := reachableBoards(g, goal)
+	var cells []int
+	for v, adj := range g.adj {
+		if len(adj) > 0 {
+			cells = append(cells, v)
+		}
+	}
+	board := slices.Clone(goal)
+	seen := map[string]bool{}
+	solvable := 0
+	for r := range factorial(len(cells)) {
+		perm := unrankPartialPermutation(r, len(cells), len(cells))
+		for i, c := range cells {
+			board[c] = goal[cells[perm[i]]]
+		}
+		key := boardKey(board)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		got := g.multisetSolvable(board, goal)
+		if want := reachable[key]; got != want {
+			t.Fatalf("%s: multisetSolvable(%v) = %v, want %v", name, board, got, want)
+		}
+		if got {
+			solvable++
+		}
+	}
+	if solvable != len(reachable) {
+		t.Errorf("%s: %d solvable boards, search reaches %d", name, solvable, len(reachable))
+	}
+}
+
+func TestMultisetSolvable_Exhaustive(t *testing.T) {
+	theta0 := graphFromEdges(7, [][2]int{{0, 2}, {2, 1}, {0, 3}, {3, 4}, {4, 1}, {0, 5}, {5, 6}, {6, 1}})
+	tests := []struct {
+		name string
+		g    *graph
+		goal []int
+	}{
+		{"2x3 distinct", gridGraph(2, 3, nil), StandardGoal(2, 3)},
+		{"2x3 pairs", gridGraph(2, 3, nil), []int{1, 1, 2, 2, 3, 6}},
+		{"2x4 one pair", gridGraph(2, 4, nil), []int{1, 1, 2, 3, 4, 5, 6, 8}},
+		{"ring", gridGraph(3, 3, lockedMask(9, 4)), []int{1, 1, 2, 2, 5, 3, 3, 4, 9}},
+		{"ring of two colors", gridGraph(3, 3, lockedMask(9, 4)), []int{1, 2, 1, 2, 5, 1, 2, 1, 9}},
+		{"theta0 one pair", theta0, []int{1, 1, 2, 3, 4, 5, 7}},
+		{"theta0 two pairs", theta0, []int{1, 2, 2, 3, 3, 4, 7}},
+		{"square with a tail", mustParseShape(t, "##..", "####").Graph().graph(nil), []int{1, 1, 2, 3, 3, 6}},
+		{"3x2 torus", wrappedGrid(3, 2, Torus, nil), []int{1, 2, 2, 3, 4, 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkMultisetSolvable(t, tt.name, tt.g, tt.goal)
+		})
+	}
+}
+
+func TestMultisetSolvable(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   []int
+		goal    []int
+		want    bool
+		wantErr error
+	}{
+		{"swap with distinct labels", []int{2, 1, 3, 4, 5, 6, 7, 8, 9}, StandardGoal(3, 3), false, nil},
+		{"swap with a repeated label", []int{2, 1, 3, 3, 9, 3, 3, 3, 3}, []int{1, 2, 3, 3, 3, 3, 3, 3, 9}, true, nil},
+		{"colors", []int{2, 1, 2, 1, 9, 1, 3, 2, 3}, []int{1, 1, 1, 2, 2, 2, 3, 3, 9}, true, nil},
+		{"different tiles", []int{2, 1, 2, 1, 9, 1, 3, 2, 2}, []int{1, 1, 1, 2, 2, 2, 3, 3, 9}, false, ErrLabelMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MultisetSolvable(tt.start, tt.goal, 3, 3)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("MultisetSolvable() = %v, %v; want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSolveMultiset(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   []int
+		goal    []int
+		rows    int
+		cols    int
+		wrap    Wrap
+		locked  []bool
+		wantErr error
+	}{
+		{"colors", []int{2, 1, 2, 1, 9, 1, 3, 2, 3}, []int{1, 1, 1, 2, 2, 2, 3, 3, 9}, 3, 3, NoWrap, nil, nil},
+		{"swap with a repeated label", []int{2, 1, 3, 3, 9, 3, 3, 3, 3}, []int{1, 2, 3, 3, 3, 3, 3, 3, 9}, 3, 3, NoWrap, nil, nil},
+		{"3x4 stripes", []int{3, 2, 1, 1, 2, 12, 3, 2, 1, 3, 1, 2}, []int{1, 1, 1, 1, 2, 2, 2, 2, 3, 3, 3, 12}, 3, 4, NoWrap, nil, nil},
+		{"distinct labels", []int{1, 8, 2, 4, 3, 5, 7, 6, 9}, StandardGoal(3, 3), 3, 3, NoWrap, nil, nil},
+		{"torus", []int{3, 2, 1, 9, 1, 3, 2, 1, 2}, []int{1, 1, 1, 2, 2, 2, 3, 3, 9}, 3, 3, Torus, nil, nil},
+		{"locked ring", []int{3, 2, 1, 4, 5, 1, 9, 3, 2}, []int{1, 1, 2, 2, 5, 3, 3, 4, 9}, 3, 3, NoWrap, lockedMask(9, 4), nil},
+		{"locked ring out of order", []int{1, 1, 2, 2, 5, 3, 4, 3, 9}, []int{1, 1, 2, 2, 5, 3, 3, 4, 9}, 3, 3, NoWrap, lockedMask(9, 4), ErrUnsolvable},
+		{"swap with distinct labels", []int{2, 1, 3, 4, 5, 6, 7, 8, 9}, StandardGoal(3, 3), 3, 3, NoWrap, nil, ErrUnsolvable},
+		{"two blanks", []int{2, 1, 2, 9, 9, 1, 3, 2, 3}, []int{1, 1, 1, 2, 2, 2, 3, 3, 9}, 3, 3, NoWrap, nil, ErrInvalidElement},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := SolveMultiset(context.Background(), tt.start, tt.goal, tt.rows, tt.cols, SolveOptions{Wrap: tt.wrap, Locked: tt.locked})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SolveMultiset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			g := wrappedGrid(tt.rows, tt.cols, tt.wrap, tt.locked)
+			for i := 1; i < len(path); i++ {
+				if !slices.Contains(g.adj[path[i-1]], path[i]) {
+					t.Fatalf("path %v moves the blank from %d to %d, which are not neighbors", path, path[i-1], path[i])
+				}
+			}
+			if got := playPath(tt.start, path); !slices.Equal(got, tt.goal) {
+				t.Fatalf("path %v ends at %v", path, got)
+			}
+			if want := lockedDistance(g, tt.start, tt.goal); len(path)-1 != want {
+				t.Errorf("path takes %d moves, want %d", len(path)-1, want)
+			}
+		})
+	}
+}
+
+func TestSolveMultiset_Metric(t *testing.T) {
+	_, err := SolveMultiset(context.Background(), []int{2, 1, 2, 1, 9, 1, 3, 2, 3}, []int{1, 1, 1, 2, 2, 2, 3, 3, 9}, 3, 3, SolveOptions{Metric: MultiTile})
+	if !errors.Is(err, ErrUnsupportedOption) {
+		t.Errorf("SolveMultiset() error = %v, want %v", err, ErrUnsupportedOption)
+	}
+}
+
+func TestMultisetGoal_Heuristic(t *testing.T) {
+	// 1 2 1   In the second board, each 1 is one step from a different goal cell of 1,
+	// 2 3 _   and so is each 2, although cell 3 is three steps from cell 2.
+	goal := []int{1, 2, 1, 2, 3, 6}
+	target := newMultisetGoal(goal, gridGraph(2, 3, nil))
+	tests := []struct {
+		board []int
+		want  int
+	}{
+		{goal, 0},
+		{[]int{2, 1, 2, 1, 3, 6}, 4},
+		{[]int{1, 1, 2, 2, 3, 6}, 2},
+		{[]int{6, 1, 2, 1, 2, 3}, 5},
+	}
+	for _, tt := range tests {
+		if got := target.heuristic(tt.board); got != tt.want {
+			t.Errorf("heuristic(%v) = %d, want %d", tt.board, got, tt.want)
+		}
+	}
+}
@@ -1,13 +1,20 @@
 package solver
 
+import "slices"
+
 // node represents a state in the search space.
 type node struct {
 	board    []int
 	blankIdx int
 	rows     int
 	cols     int
-	cost     int   // g(n): cost from start
-	parent   *node // parent node to reconstruct the path
+	cost     int     // g(n): cost from start
+	parent   *node   // parent node to reconstruct the path
+	locked   []bool  // optional; cells the blank may not enter, shared by the whole search
+	adj      [][]int // optional; replaces the grid moves with these neighbor lists, shared by the whole search
+	wrap     Wrap    // which edges of the board the blank may cross
+	blanks   int     // number of cells holding the blank; blankIdx is the one that moved last
+	metric   Metric  // what counts as one move
 }
 
 const (
@@ -28,10 +35,13 @@ func newNode(input []int, rows, cols int) *node {
 	copy(board, input)
 
 	blankIdx := -1
+	blanks := 0
 	for i, v := range board {
 		if v == len(board) {
-			blankIdx = i
-			break
+			if blanks == 0 {
+				blankIdx = i
+			}
+			blanks++
 		}
 	}
 
@@ -40,6 +50,7 @@ func newNode(input []int, rows, cols int) *node {
 		blankIdx: blankIdx,
 		rows:     rows,
 		cols:     cols,
+		blanks:   blanks,
 	}
 }
 
@@ -97,6 +108,139 @@ func (n *node) leftNode() *node {
 	return &next
 }
 
+// children returns the nodes reachable by moving the blank tile one step in any possible direction,
+// or to any of its neighbors when the node has neighbor lists. With several blanks, each of them
+// may move, but never into another blank.
+func (n *node) children() []*node {
+	var children []*node
+	if n.blanks > 1 {
+		blank := len(n.board)
+		for i, v := range n.board {
+			if v != blank {
+				continue
+			}
+			from := *n
+			from.blankIdx = i
+			for _, dir := range from.directions() {
+				if w := from.neighbor(dir); n.board[w] != blank {
+					children = append(children, n.slideFrom(i, w))
+				}
+			}
+		}
+		return children
+	}
+	if n.adj != nil {
+		for _, w := range n.adj[n.blankIdx] {
+			children = append(children, n.slide(w))
+		}
+		return children
+	}
+	if n.metric == MultiTile {
+		return n.segmentChildren()
+	}
+	for _, dir := range n.directions() {
+		children = append(children, n.child(dir))
+	}
+	return children
+}
+
+// segmentChildren returns the nodes reachable by sliding the blank any number of cells in
+// one direction, a single move in the multi-tile metric. The cells in between are kept as
+// parents, so that path still lists every cell the blank visits.
+//
+// Two moves along the same line make one move or none, so after the first move only the
+// other axis is tried, except for moves that continue around a wrapping line, which may
+// take the blank past its starting cell.
+func (n *node) segmentChildren() []*node {
+	var children []*node
+	last := -1
+	if n.parent != nil {
+		last = stepDirection(n.parent.blankIdx, n.blankIdx, n.cols)
+	}
+	for _, dir := range n.directions() {
+		if last != -1 && isHorizontal(dir) == isHorizontal(last) && !(dir == last && n.wraps(dir)) {
+			continue
+		}
+		for m := n.child(dir); ; m = m.child(dir) {
+			m.cost = n.cost + 1
+			children = append(children, m)
+			if !m.canMove(dir) || m.neighbor(dir) == n.blankIdx {
+				break
+			}
+		}
+	}
+	return children
+}
+
+// directions returns the directions in which the blank tile can be moved.
+func (n *node) directions() []int {
+	var dirs []int
+	if n.canMoveUp() {
+		dirs = append(dirs, up)
+	}
+	if n.canMoveDown() {
+		dirs = append(dirs, down)
+	}
+	if n.canMoveLeft() {
+		dirs = append(dirs, left)
+	}
+	if n.canMoveRight() {
+		dirs = append(dirs, right)
+	}
+	return dirs
+}
+
+// child creates a new child node by moving the blank tile in the given direction.
+func (n *node) child(dir int) *node {
+	next := n.copy()
+	next.moveBlank(dir)
+	next.parent = n
+	next.cost = n.cost + 1
+	return &next
+}
+
+// slide creates a new child node by moving the blank tile to the neighboring cell w.
+func (n *node) slide(w int) *node {
+	return n.slideFrom(n.blankIdx, w)
+}
+
+// slideFrom creates a new child node by moving the blank in cell b to the neighboring cell w.
+func (n *node) slideFrom(b, w int) *node {
+	next := n.copy()
+	next.swap(b, w)
+	next.blankIdx = w
+	next.parent = n
+	next.cost = n.cost + 1
+	return &next
+}
+
+// path returns the blank tile indices from the root of the search to this node.
+func (n *node) path() []int {
+	var path []int
+	for current := n; current != nil; current = current.parent {
+		path = append(path, current.blankIdx)
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// tileMoves returns the tile moves from the root of the search to this node. The tile of
+// each move comes from the cell the blank now occupies and goes to the cell that changed
+// along with it.
+func (n *node) tileMoves() []TileMove {
+	var moves []TileMove
+	for current := n; current.parent != nil; current = current.parent {
+		for i, v := range current.board {
+			if i != current.blankIdx && v != current.parent.board[i] {
+				moves = append(moves, TileMove{Tile: v, From: current.blankIdx, To: i})
+				break
+			}
+		}
+	}
+	slices.Reverse(moves)
+	return moves
+}
+
 // copy creates a deep copy of the current node.
 func (n *node) copy() node {
 	board := make([]int, len(n.board))
@@ -108,6 +252,11 @@ func (n *node) copy() node {
 		cols:     n.cols,
 		cost:     n.cost,
 		parent:   n.parent,
+		locked:   n.locked,
+		adj:      n.adj,
+		wrap:     n.wrap,
+		blanks:   n.blanks,
+		metric:   n.metric,
 	}
 }
 
@@ -136,46 +285,84 @@ func (n *node) moveRight() {
 }
 
 // canMoveUp checks if the blank tile can be moved up.
-// It returns true if the blank tile is not in the top row.
+// It returns true if the blank tile is not in the top row, or the board wraps vertically,
+// and the cell above is not locked.
 func (n *node) canMoveUp() bool {
-	return n.blankIdx >= n.cols
+	return (n.blankIdx >= n.cols || n.wrap.vertical(n.rows)) && !n.isLocked(n.neighbor(up))
 }
 
 // canMoveDown checks if the blank tile can be moved down.
-// It returns true if the blank tile is not in the bottom row.
+// It returns true if the blank tile is not in the bottom row, or the board wraps vertically,
+// and the cell below is not locked.
 func (n *node) canMoveDown() bool {
-	return n.blankIdx < len(n.board)-n.cols
+	return (n.blankIdx < len(n.board)-n.cols || n.wrap.vertical(n.rows)) && !n.isLocked(n.neighbor(down))
 }
 
 // canMoveLeft checks if the blank tile can be moved left.
-// It returns true if the blank tile is not in the first column.
+// It returns true if the blank tile is not in the first column, or the board wraps
+// horizontally, and the cell to the left is not locked.
 func (n *node) canMoveLeft() bool {
-	return n.blankIdx%n.cols != 0
+	return (n.blankIdx%n.cols != 0 || n.wrap.horizontal(n.cols)) && !n.isLocked(n.neighbor(left))
 }
 
 // canMoveRight checks if the blank tile can be moved right.
-// It returns true if the blank tile is not in the last column.
+// It returns true if the blank tile is not in the last column, or the board wraps
+// horizontally, and the cell to the right is not locked.
 func (n *node) canMoveRight() bool {
-	return n.blankIdx%n.cols != n.cols-1
+	return (n.blankIdx%n.cols != n.cols-1 || n.wrap.horizontal(n.cols)) && !n.isLocked(n.neighbor(right))
 }
 
-// moveBlank moves the blank tile in the specified direction.
-// It swaps the blank tile with the adjacent tile and updates the blank index.
-func (n *node) moveBlank(dir int) {
+// canMove reports whether the blank tile can be moved in the given direction.
+func (n *node) canMove(dir int) bool {
 	switch dir {
 	case up:
-		n.swap(n.blankIdx, n.blankIdx-n.cols)
-		n.blankIdx -= n.cols
+		return n.canMoveUp()
 	case down:
-		n.swap(n.blankIdx, n.blankIdx+n.cols)
-		n.blankIdx += n.cols
+		return n.canMoveDown()
 	case left:
-		n.swap(n.blankIdx, n.blankIdx-1)
-		n.blankIdx--
+		return n.canMoveLeft()
+	default:
+		return n.canMoveRight()
+	}
+}
+
+// wraps reports whether the line the blank moves along in the given direction wraps
+// around the board.
+func (n *node) wraps(dir int) bool {
+	if isHorizontal(dir) {
+		return n.wrap.horizontal(n.cols)
+	}
+	return n.wrap.vertical(n.rows)
+}
+
+// neighbor returns the cell next to the blank tile in the given direction, wrapping
+// around the edges of the board.
+func (n *node) neighbor(dir int) int {
+	r, c := n.blankIdx/n.cols, n.blankIdx%n.cols
+	switch dir {
+	case up:
+		r = (r + n.rows - 1) % n.rows
+	case down:
+		r = (r + 1) % n.rows
+	case left:
+		c = (c + n.cols - 1) % n.cols
 	case right:
-		n.swap(n.blankIdx, n.blankIdx+1)
-		n.blankIdx++
+		c = (c + 1) % n.cols
 	}
+	return r*n.cols + c
+}
+
+// isLocked reports whether the tile in cell i may not be moved.
+func (n *node) isLocked(i int) bool {
+	return n.locked != nil && n.locked[i]
+}
+
+// moveBlank moves the blank tile in the specified direction.
+// It swaps the blank tile with the adjacent tile and updates the blank index.
+func (n *node) moveBlank(dir int) {
+	next := n.neighbor(dir)
+	n.swap(n.blankIdx, next)
+	n.blankIdx = next
 }
 
 // swap swaps the values at indices i and j in the board.
@@ -133,3 +133,93 @@ func TestNode_ChildNodes(t *testing.T) {
 		t.Error("upNode() should not modify original node")
 	}
 }
+
+func TestNode_CanMoveLocked(t *testing.T) {
+	// 1 2 3
+	// 4 9 6   the blank is in the center; cells 1 and 5 are locked.
+	// 7 8 5
+	n := newNode([]int{1, 2, 3, 4, 9, 6, 7, 8, 5}, 3, 3)
+	n.locked = []bool{false, true, false, false, false, true, false, false, false}
+	if got, want := n.directions(), []int{down, left}; !reflect.DeepEqual(got, want) {
+		t.Errorf("directions() = %v, want %v", got, want)
+	}
+	if c := n.copy(); !reflect.DeepEqual(c.locked, n.locked) {
+		t.Error("copy() should keep the locked cells")
+	}
+}
+
+func TestNode_CanMoveWrap(t *testing.T) {
+	// 9 2 3
+	// 4 5 6   the blank is in the top-left corner.
+	// 7 8 1
+	board := []int{9, 2, 3, 4, 5, 6, 7, 8, 1}
+	tests := []struct {
+		wrap     Wrap
+		want     []int
+		upCell   int // the cell the blank reaches by moving up
+		leftCell int // and by moving left
+	}{
+		{NoWrap, []int{down, right}, -1, -1},
+		{Cylinder, []int{down, left, right}, -1, 2},
+		{Torus, []int{up, down, left, right}, 6, 2},
+	}
+	for _, tt := range tests {
+		n := newNode(board, 3, 3)
+		n.wrap = tt.wrap
+		if got := n.directions(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v: directions() = %v, want %v", tt.wrap, got, tt.want)
+		}
+		if tt.upCell >= 0 {
+			if got := n.child(up); got.blankIdx != tt.upCell || got.board[tt.upCell] != 9 || got.board[0] != 7 {
+				t.Errorf("%v: moving up gives %v", tt.wrap, got.board)
+			}
+		}
+		if tt.leftCell >= 0 {
+			if got := n.child(left); got.blankIdx != tt.leftCell || got.board[0] != 3 {
+				t.Errorf("%v: moving left gives %v", tt.wrap, got.board)
+			}
+		}
+	}
+}
+
+func TestNode_ChildrenMultiBlank(t *testing.T) {
+	// 6 1 2
+	// 6 3 4   both blanks may move right; neither may move into the other.
+	n := newNode([]int{6, 1, 2, 6, 3, 4}, 2, 3)
+	if n.blanks != 2 || n.blankIdx != 0 {
+		t.Fatalf("newNode() has %d blanks, the first at %d; want 2 at 0", n.blanks, n.blankIdx)
+	}
+	children := n.children()
+	if len(children) != 2 {
+		t.Fatalf("children() returned %d nodes, want 2", len(children))
+	}
+	want := []TileMove{{Tile: 3, From: 4, To: 3}}
+	if got := children[1].tileMoves(); !reflect.DeepEqual(got, want) {
+		t.Errorf("tileMoves() = %+v, want %+v", got, want)
+	}
+}
+
+func TestNode_SegmentChildren(t *testing.T) {
+	n := newNode(StandardGoal(3, 3), 3, 3)
+	n.metric = MultiTile
+	children := n.children()
+	var paths [][]int
+	for _, c := range children {
+		if c.cost != 1 {
+			t.Errorf("child %v costs %d, want 1", c.board, c.cost)
+		}
+		paths = append(paths, c.path())
+	}
+	want := [][]int{{8, 5}, {8, 5, 2}, {8, 7}, {8, 7, 6}}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("children() paths = %v, want %v", paths, want)
+	}
+	// After a move along the bottom row, only vertical moves remain.
+	var dirs []int
+	for _, c := range children[3].children() {
+		dirs = append(dirs, stepDirection(c.parent.blankIdx, c.blankIdx, 3))
+	}
+	if want := []int{up, up}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("directions after a horizontal move = %v, want %v", dirs, want)
+	}
+}
@@ -0,0 +1,366 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var ErrInvalidPartialGoal = errors.New("invalid partial goal")
+
+// Wildcard marks a cell of PartialGoal.Cells that may hold any tile.
+const Wildcard = 0
+
+// PartialGoal describes a set of solved configurations: every board that puts the
+// required tiles in their cells and every grouped tile in one of its group's cells.
+// Tiles that are neither required nor grouped may end anywhere, and so may the blank
+// unless it is required too.
+//
+// Example:
+//
+//	// Finish the top row of the 8-puzzle.
+//	top := PartialGoal{Cells: []int{1, 2, 3, Wildcard, Wildcard, Wildcard, Wildcard, Wildcard, Wildcard}}
+//	// Put tiles 1, 4 and 7 anywhere in the left column.
+//	left := PartialGoal{Groups: []TileGroup{{Tiles: []int{1, 4, 7}, Cells: []int{0, 3, 6}}}}
+type PartialGoal struct {
+	// Cells holds the tile required in each cell, or Wildcard. Nil leaves every cell to Groups.
+	Cells []int `json:"cells,omitempty"`
+	// Groups lists sets of tiles that must fill sets of cells in any order.
+	Groups []TileGroup `json:"groups,omitempty"`
+}
+
+// TileGroup requires its tiles to occupy its cells, in any order.
+// Tiles and Cells have the same length.
+type TileGroup struct {
+	Tiles []int `json:"tiles"`
+	Cells []int `json:"cells"`
+}
+
+// Matches reports whether board, a valid configuration of the goal's shape, is solved.
+func (g PartialGoal) Matches(board []int, rows, cols int) (bool, error) {
+	if err := validate(board, rows, cols); err != nil {
+		return false, err
+	}
+	p, err := g.compile(rows, cols)
+	if err != nil {
+		return false, err
+	}
+	return p.matches(board), nil
+}
+
+// PartialSolvable reports whether start can reach any configuration matching goal.
+// A partial goal with two free tiles, or two tiles in the same group, can be reached
+// from every board, since either order of those tiles is solved.
+func PartialSolvable(start []int, goal PartialGoal, rows, cols int) (bool, error) {
+	if err := validate(start, rows, cols); err != nil {
+		return false, err
+	}
+	p, err := goal.compile(rows, cols)
+	if err != nil {
+		return false, err
+	}
+	return p.solvable(start), nil
+}
+
+// SolvePartial finds a shortest path from start to any configuration matching goal.
+// The path has the form returned by Solve. opts.Locked works as for SolveWithOptions:
+// the goal may only require locked cells to keep their tiles. opts.Cache is not
+// consulted, since cached solutions belong to complete goals, and opts.Wrap and the
+// MultiTile metric return ErrUnsupportedOption.
+//
+// Example:
+//
+//	top := PartialGoal{Cells: []int{1, 2, 3, 0, 0, 0, 0, 0, 0}}
+//	path, err := SolvePartial(ctx, []int{3, 1, 2, 4, 5, 6, 7, 8, 9}, top, 3, 3, SolveOptions{})
+func SolvePartial(ctx context.Context, start []int, goal PartialGoal, rows, cols int, opts SolveOptions) ([]int, error) {
+	if err := validate(start, rows, cols); err != nil {
+		return nil, err
+	}
+	if err := unsupportedOptions(opts, "partial goals"); err != nil {
+		return nil, err
+	}
+	if opts.Locked != nil {
+		if err := goal.validateLocked(start, opts.Locked); err != nil {
+			return nil, err
+		}
+		frozen, err := goal.freeze(start, opts.Locked)
+		if err != nil {
+			return nil, err
+		}
+		goal = frozen
+	}
+	p, err := goal.compile(rows, cols)
+	if err != nil {
+		return nil, err
+	}
+	var solvable bool
+	if opts.Locked != nil {
+		solvable = p.solvableOn(start, gridGraph(rows, cols, opts.Locked))
+	} else {
+		solvable = p.solvable(start)
+	}
+	if !solvable {
+		return nil, ErrUnsolvable
+	}
+
+	s := &searcher{ctx: ctx, progress: opts.Progress, target: p, rows: rows, cols: cols}
+	root := newNode(start, rows, cols)
+	root.locked = opts.Locked
+	found, err := s.run(root)
+	if opts.Stats != nil {
+		*opts.Stats = Stats{NodesExpanded: s.expanded, Iterations: s.iterations}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return found.path(), nil
+}
+
+// validateLocked checks a mask of locked cells against start and the goal: locked cells
+// may not hold the blank, and the goal may only require them to keep their tiles.
+func (g PartialGoal) validateLocked(start []int, locked []bool) error {
+	if len(locked) != len(start) {
+		return ErrLockedMask
+	}
+	for c, l := range locked {
+		if !l {
+			continue
+		}
+		v := start[c]
+		if v == len(start) {
+			return ErrLockedBlank
+		}
+		if i := slices.Index(g.Cells, v); i >= 0 && i != c || c < len(g.Cells) && g.Cells[c] != Wildcard && g.Cells[c] != v {
+			return ErrLockedTile
+		}
+		for _, group := range g.Groups {
+			if slices.Contains(group.Tiles, v) != slices.Contains(group.Cells, c) {
+				return ErrLockedTile
+			}
+		}
+	}
+	return nil
+}
+
+// unsupportedOptions returns ErrUnsupportedOption, naming what is being solved, when opts
+// wraps moves around the edges or counts them in the MultiTile metric.
+func unsupportedOptions(opts SolveOptions, what string) error {
+	if opts.Wrap != NoWrap {
+		return fmt.Errorf("%w: %s on a %v", ErrUnsupportedOption, what, opts.Wrap)
+	}
+	if opts.Metric != SingleTile {
+		return fmt.Errorf("%w: %s in the %v metric", ErrUnsupportedOption, what, opts.Metric)
+	}
+	return nil
+}
+
+// partialGoal is a validated PartialGoal prepared for searching.
+type partialGoal struct {
+	rows  int
+	cols  int
+	fixed []int // the required tile of each cell, or Wildcard
+	// tileGroup and cellGroup hold 1 + the index of the group of each tile and cell,
+	// or 0 for tiles and cells outside groups.
+	tileGroup []int
+	cellGroup []int
+	groups    []TileGroup // groups of two or more cells
+}
+
+// compile validates the goal and prepares it for a rows x cols board of a valid size.
+// Groups of a single cell are turned into required tiles.
+func (g PartialGoal) compile(rows, cols int) (*partialGoal, error) {
+	n := rows * cols
+	p := &partialGoal{rows: rows, cols: cols, fixed: make([]int, n), tileGroup: make([]int, n+1), cellGroup: make([]int, n)}
+	if g.Cells != nil {
+		if len(g.Cells) != n {
+			return nil, fmt.Errorf("%w: %d cells for a %dx%d board", ErrInvalidPartialGoal, len(g.Cells), rows, cols)
+		}
+		copy(p.fixed, g.Cells)
+	}
+
+	used := make([]bool, n+1)
+	for i, v := range p.fixed {
+		if v < 0 || v > n {
+			return nil, fmt.Errorf("%w: tile %d out of range at position %d", ErrInvalidPartialGoal, v, i)
+		}
+		if v == Wildcard {
+			continue
+		}
+		if used[v] {
+			return nil, fmt.Errorf("%w: tile %d is required twice", ErrInvalidPartialGoal, v)
+		}
+		used[v] = true
+	}
+
+	for _, group := range g.Groups {
+		if len(group.Tiles) != len(group.Cells) || len(group.Tiles) == 0 {
+			return nil, fmt.Errorf("%w: group of %d tiles for %d cells", ErrInvalidPartialGoal, len(group.Tiles), len(group.Cells))
+		}
+		for _, v := range group.Tiles {
+			if v < 1 || v > n {
+				return nil, fmt.Errorf("%w: grouped tile %d out of range", ErrInvalidPartialGoal, v)
+			}
+			if used[v] {
+				return nil, fmt.Errorf("%w: tile %d is required twice", ErrInvalidPartialGoal, v)
+			}
+			used[v] = true
+		}
+		for _, c := range group.Cells {
+			if c < 0 || c >= n {
+				return nil, fmt.Errorf("%w: grouped cell %d out of range", ErrInvalidPartialGoal, c)
+			}
+			if p.fixed[c] != Wildcard || p.cellGroup[c] != 0 {
+				return nil, fmt.Errorf("%w: cell %d is constrained twice", ErrInvalidPartialGoal, c)
+			}
+			p.cellGroup[c] = -1
+		}
+
+		if len(group.Cells) == 1 {
+			p.fixed[group.Cells[0]] = group.Tiles[0]
+			p.cellGroup[group.Cells[0]] = 0
+			continue
+		}
+		p.groups = append(p.groups, TileGroup{Tiles: slices.Clone(group.Tiles), Cells: slices.Clone(group.Cells)})
+		for _, v := range group.Tiles {
+			p.tileGroup[v] = len(p.groups)
+		}
+		for _, c := range group.Cells {
+			p.cellGroup[c] = len(p.groups)
+		}
+	}
+	return p, nil
+}
+
+// matches reports whether a valid board is solved.
+func (p *partialGoal) matches(board []int) bool {
+	for i, v := range board {
+		if p.fixed[i] != Wildcard && v != p.fixed[i] {
+			return false
+		}
+		if g := p.tileGroup[v]; g != 0 && p.cellGroup[i] != g {
+			return false
+		}
+	}
+	return true
+}
+
+// heuristic is the Manhattan distance and linear conflict of the required tiles plus the
+// distance of each grouped tile to the nearest cell of its group. Free tiles are ignored,
+// which keeps the estimate admissible.
+func (p *partialGoal) heuristic(board []int) int {
+	// calculateHeuristic skips tiles missing from the goal, so the wildcards drop out.
+	h := calculateHeuristic(board, p.fixed, p.rows, p.cols)
+	blank := len(board)
+	for i, v := range board {
+		g := p.tileGroup[v]
+		if g == 0 || v == blank || p.cellGroup[i] == g {
+			continue
+		}
+		nearest := len(board) * 2
+		for _, c := range p.groups[g-1].Cells {
+			nearest = min(nearest, manhattanDistance(i, c, p.rows, p.cols))
+		}
+		h += nearest
+	}
+	return h
+}
+
+// solvable reports whether start can reach a matching configuration.
+// The parity argument decides it for one completion of the goal; when that fails, a
+// matching configuration of the other parity exists exactly when one pool of
+// interchangeable tiles, a group or the free tiles, can swap two of its members:
+// two non-blank tiles, or the blank and a tile an even distance apart.
+func (p *partialGoal) solvable(start []int) bool {
+	completion, pools := p.complete()
+	if isSolvable(start, completion, p.rows, p.cols) {
+		return true
+	}
+	blank := len(start)
+	for _, pool := range pools {
+		tiles := len(pool.Tiles)
+		if slices.Contains(pool.Tiles, blank) {
+			tiles--
+		}
+		if tiles >= 2 {
+			return true
+		}
+		if tiles == 1 && len(pool.Cells) == 2 && manhattanDistance(pool.Cells[0], pool.Cells[1], p.rows, p.cols)%2 == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// solvableOn is solvable for a blank moving along g, which may leave out locked cells.
+// Giving the tiles of each pool one label turns the question into that of
+// multisetSolvable, asked for every cell of its pool the blank may end in.
+func (p *partialGoal) solvableOn(start []int, g *graph) bool {
+	n := len(start)
+	completion, pools := p.complete()
+	label := make([]int, n+1)
+	for v := range label {
+		label[v] = v
+	}
+	blankCells := []int{slices.Index(completion, n)}
+	for _, pool := range pools {
+		first := 0
+		for _, v := range pool.Tiles {
+			switch {
+			case v == n:
+				blankCells = pool.Cells
+			case first == 0:
+				first = v
+				fallthrough
+			default:
+				label[v] = first
+			}
+		}
+	}
+
+	from, to := make([]int, n), make([]int, n)
+	for i := range start {
+		from[i], to[i] = label[start[i]], label[completion[i]]
+	}
+	blank := slices.Index(to, n)
+	for _, c := range blankCells {
+		// The other cells of the blank's pool hold the same label.
+		to[blank], to[c] = to[c], to[blank]
+		blank = c
+		if g.multisetSolvable(from, to) {
+			return true
+		}
+	}
+	return false
+}
+
+// complete returns one matching configuration together with the pools of tiles that
+// may be permuted among their cells: the groups and the free tiles with the free cells.
+func (p *partialGoal) complete() ([]int, []TileGroup) {
+	n := len(p.fixed)
+	board := slices.Clone(p.fixed)
+	placed := make([]bool, n+1)
+	for _, v := range board {
+		placed[v] = true
+	}
+	for _, g := range p.groups {
+		for i, c := range g.Cells {
+			board[c] = g.Tiles[i]
+			placed[g.Tiles[i]] = true
+		}
+	}
+
+	free := TileGroup{}
+	for v := 1; v <= n; v++ {
+		if !placed[v] {
+			free.Tiles = append(free.Tiles, v)
+		}
+	}
+	for c, v := range board {
+		if v == Wildcard {
+			board[c] = free.Tiles[len(free.Cells)]
+			free.Cells = append(free.Cells, c)
+		}
+	}
+	return board, append(slices.Clone(p.groups), free)
+}
@@ -0,0 +1,282 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// partialDistance returns the optimal number of moves from start to a board matching
+// goal by breadth-first search, or -1 when none is reachable.
+func partialDistance(t *testing.T, start []int, goal PartialGoal, rows, cols int) int {
+	t.Helper()
+	p, err := goal.compile(rows, cols)
+	if err != nil {
+		t.Fatalf("compile() error = %v", err)
+	}
+	seen := map[string]bool{boardKey(start): true}
+	frontier := []*node{newNode(start, rows, cols)}
+	for d := 0; len(frontier) > 0; d++ {
+		var next []*node
+		for _, n := range frontier {
+			if p.matches(n.board) {
+				return d
+			}
+			for _, child := range n.children() {
+				if key := boardKey(child.board); !seen[key] {
+					seen[key] = true
+					next = append(next, child)
+				}
+			}
+		}
+		frontier = next
+	}
+	return -1
+}
+
+func TestSolvePartial(t *testing.T) {
+	w := Wildcard
+	tests := []struct {
+		name    string
+		start   []int
+		goal    PartialGoal
+		rows    int
+		cols    int
+		wantErr error
+	}{
+		{"top row", []int{3, 1, 2, 4, 5, 6, 7, 8, 9}, PartialGoal{Cells: []int{1, 2, 3, w, w, w, w, w, w}}, 3, 3, nil},
+		{"top row already done", []int{1, 2, 3, 9, 8, 7, 6, 5, 4}, PartialGoal{Cells: []int{1, 2, 3, w, w, w, w, w, w}}, 3, 3, nil},
+		{"left column in any order", []int{9, 1, 2, 3, 4, 5, 6, 7, 8}, PartialGoal{Groups: []TileGroup{{Tiles: []int{1, 4, 7}, Cells: []int{0, 3, 6}}}}, 3, 3, nil},
+		{"blank required", []int{8, 7, 6, 5, 4, 3, 2, 1, 9}, PartialGoal{Cells: []int{9, w, w, w, w, w, w, w, w}}, 3, 3, nil},
+		{"tiles and group", []int{8, 6, 7, 2, 5, 4, 3, 9, 1}, PartialGoal{Cells: []int{1, w, w, w, w, w, w, w, w}, Groups: []TileGroup{{Tiles: []int{2, 3}, Cells: []int{1, 2}}}}, 3, 3, nil},
+		{"single-cell group", []int{4, 1, 3, 2, 5, 6}, PartialGoal{Groups: []TileGroup{{Tiles: []int{1}, Cells: []int{0}}}}, 2, 3, nil},
+		// Tile 4 and the blank share cells 3 and 5, two steps apart: either order is solved.
+		{"other parity through the free tiles", []int{2, 1, 3, 4, 5, 6}, PartialGoal{Cells: []int{1, 2, 3, w, 5, w}}, 2, 3, nil},
+		// Tile 3 and the blank share adjacent cells: both completions have the same parity.
+		{"unsolvable", []int{2, 1, 3, 4}, PartialGoal{Cells: []int{1, 2, w, w}}, 2, 2, ErrUnsolvable},
+		{"complete goal unsolvable", []int{2, 1, 3, 4}, PartialGoal{Cells: []int{1, 2, 3, 4}}, 2, 2, ErrUnsolvable},
+		{"invalid start", []int{1, 2, 3}, PartialGoal{}, 2, 2, ErrSizeMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := SolvePartial(context.Background(), tt.start, tt.goal, tt.rows, tt.cols, SolveOptions{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SolvePartial() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if tt.wantErr == ErrUnsolvable && partialDistance(t, tt.start, tt.goal, tt.rows, tt.cols) != -1 {
+					t.Error("SolvePartial() reports an unsolvable goal that can be reached")
+				}
+				return
+			}
+			end := playPath(tt.start, path)
+			if ok, _ := tt.goal.Matches(end, tt.rows, tt.cols); !ok {
+				t.Errorf("SolvePartial() path ends at %v, which does not match the goal", end)
+			}
+			if want := partialDistance(t, tt.start, tt.goal, tt.rows, tt.cols); len(path)-1 != want {
+				t.Errorf("SolvePartial() takes %d moves, want %d", len(path)-1, want)
+			}
+		})
+	}
+}
+
+func TestPartialSolvable(t *testing.T) {
+	// Against breadth-first search on every 2x3 board for goals with few free tiles.
+	w := Wildcard
+	goals := []PartialGoal{
+		{Cells: []int{1, 2, 3, 4, 5, w}},
+		{Cells: []int{1, 2, 3, w, 5, w}},
+		{Cells: []int{1, 2, 3, 4, w, w}},
+		{Cells: []int{1, 2, w, 4, 5, 6}},
+		{Cells: []int{6, w, w, 4, 5, 3}, Groups: []TileGroup{{Tiles: []int{1, 2}, Cells: []int{1, 2}}}},
+		{Groups: []TileGroup{{Tiles: []int{6, 5}, Cells: []int{0, 5}}, {Tiles: []int{1, 2, 3, 4}, Cells: []int{1, 2, 3, 4}}}},
+	}
+	for _, goal := range goals {
+		for _, start := range [][]int{{1, 2, 3, 4, 5, 6}, {2, 1, 3, 4, 5, 6}, {6, 1, 2, 3, 4, 5}, {1, 6, 2, 3, 5, 4}} {
+			got, err := PartialSolvable(start, goal, 2, 3)
+			if err != nil {
+				t.Fatalf("PartialSolvable(%v, %+v) error = %v", start, goal, err)
+			}
+			if want := partialDistance(t, start, goal, 2, 3) != -1; got != want {
+				t.Errorf("PartialSolvable(%v, %+v) = %v, want %v", start, goal, got, want)
+			}
+		}
+	}
+}
+
+func TestPartialGoal_SolvableOn(t *testing.T) {
+	// Against breadth-first search from every matching board, for every board that keeps
+	// the standard goal's tiles in the locked cells.
+	w := Wildcard
+	tests := []struct {
+		name   string
+		rows   int
+		cols   int
+		goal   PartialGoal
+		locked []bool
+	}{
+		{"ring", 3, 3, PartialGoal{Cells: StandardGoal(3, 3)}, lockedMask(9, 4)},
+		{"ring with a free row", 3, 3, PartialGoal{Cells: []int{1, 2, 3, w, 5, w, w, w, w}}, lockedMask(9, 4)},
+		{"ring with a free blank", 3, 3, PartialGoal{Cells: []int{1, 2, 3, 4, 5, w, 7, w, w}}, lockedMask(9, 4)},
+		{"corner locked", 3, 3, PartialGoal{Cells: []int{1, w, w, w, 5, w, w, w, 9}, Groups: []TileGroup{{Tiles: []int{2, 4}, Cells: []int{1, 3}}}}, lockedMask(9, 0)},
+		{"group with the blank", 2, 3, PartialGoal{Cells: []int{1, 2, 3, w, w, w}, Groups: []TileGroup{{Tiles: []int{4, 6}, Cells: []int{3, 5}}}}, lockedMask(6, 0)},
+		{"tail", 2, 3, PartialGoal{Cells: StandardGoal(2, 3)}, lockedMask(6, 1)},
+		{"no locks", 2, 3, PartialGoal{Cells: []int{1, w, 3, w, w, 6}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := tt.goal.compile(tt.rows, tt.cols)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g := gridGraph(tt.rows, tt.cols, tt.locked)
+			n := tt.rows * tt.cols
+			goal := StandardGoal(tt.rows, tt.cols)
+			var boards [][]int
+			reached := map[string]bool{}
+			var queue [][]int
+			for r := range factorial(n) {
+				board := unrankPartialPermutation(r, n, n)
+				kept := true
+				for i := range board {
+					board[i]++
+					kept = kept && (tt.locked == nil || !tt.locked[i] || board[i] == goal[i])
+				}
+				if !kept {
+					continue
+				}
+				boards = append(boards, board)
+				if p.matches(board) {
+					reached[boardKey(board)] = true
+					queue = append(queue, board)
+				}
+			}
+			for len(queue) > 0 {
+				b := queue[0]
+				queue = queue[1:]
+				blank := slices.Index(b, n)
+				for _, v := range g.adj[blank] {
+					next := slices.Clone(b)
+					next[blank], next[v] = next[v], next[blank]
+					if key := boardKey(next); !reached[key] {
+						reached[key] = true
+						queue = append(queue, next)
+					}
+				}
+			}
+			for _, b := range boards {
+				if got, want := p.solvableOn(b, g), reached[boardKey(b)]; got != want {
+					t.Fatalf("solvableOn(%v) = %v, want %v", b, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPartialGoal_Matches(t *testing.T) {
+	w := Wildcard
+	goal := PartialGoal{Cells: []int{1, w, w, w, w, w, w, w, w}, Groups: []TileGroup{{Tiles: []int{2, 3}, Cells: []int{1, 2}}}}
+	tests := []struct {
+		board []int
+		want  bool
+	}{
+		{[]int{1, 2, 3, 4, 5, 6, 7, 8, 9}, true},
+		{[]int{1, 3, 2, 9, 8, 7, 6, 5, 4}, true},
+		{[]int{1, 3, 4, 2, 5, 6, 7, 8, 9}, false},
+		{[]int{2, 1, 3, 4, 5, 6, 7, 8, 9}, false},
+	}
+	for _, tt := range tests {
+		if got, err := goal.Matches(tt.board, 3, 3); got != tt.want || err != nil {
+			t.Errorf("Matches(%v) = %v, %v; want %v", tt.board, got, err, tt.want)
+		}
+	}
+}
+
+func TestPartialGoal_Invalid(t *testing.T) {
+	w := Wildcard
+	for _, goal := range []PartialGoal{
+		{Cells: []int{1, 2, 3}},
+		{Cells: []int{1, 1, w, w}},
+		{Cells: []int{5, w, w, w}},
+		{Cells: []int{-1, w, w, w}},
+		{Groups: []TileGroup{{Tiles: []int{1, 2}, Cells: []int{0}}}},
+		{Groups: []TileGroup{{Tiles: []int{1}, Cells: []int{4}}}},
+		{Groups: []TileGroup{{Tiles: []int{0}, Cells: []int{1}}}},
+		{Cells: []int{1, w, w, w}, Groups: []TileGroup{{Tiles: []int{1}, Cells: []int{1}}}},
+		{Cells: []int{1, w, w, w}, Groups: []TileGroup{{Tiles: []int{2}, Cells: []int{0}}}},
+		{Groups: []TileGroup{{Tiles: []int{1, 2}, Cells: []int{0, 0}}}},
+	} {
+		if _, err := PartialSolvable([]int{1, 2, 3, 4}, goal, 2, 2); !errors.Is(err, ErrInvalidPartialGoal) {
+			t.Errorf("PartialSolvable(%+v) error = %v, want %v", goal, err, ErrInvalidPartialGoal)
+		}
+	}
+}
+
+func TestPartialGoal_Heuristic(t *testing.T) {
+	// The estimate never exceeds the true distance.
+	w := Wildcard
+	goal := PartialGoal{Cells: []int{1, 2, w, w, w, w, w, w, 9}, Groups: []TileGroup{{Tiles: []int{4, 7}, Cells: []int{3, 6}}}}
+	p, err := goal.compile(3, 3)
+	if err != nil {
+		t.Fatalf("compile() error = %v", err)
+	}
+	for _, board := range [][]int{
+		{9, 8, 7, 6, 5, 4, 3, 2, 1},
+		{2, 1, 3, 7, 5, 6, 4, 8, 9},
+		{4, 7, 9, 1, 2, 3, 5, 6, 8},
+	} {
+		if h, d := p.heuristic(board), partialDistance(t, board, goal, 3, 3); h > d {
+			t.Errorf("heuristic(%v) = %d exceeds the distance %d", board, h, d)
+		}
+	}
+}
+
+func TestSolvePartial_Options(t *testing.T) {
+	w := Wildcard
+	top := PartialGoal{Cells: []int{1, 2, 3, w, w, w, w, w, w}}
+	tests := []struct {
+		name    string
+		start   []int
+		goal    PartialGoal
+		opts    SolveOptions
+		wantErr error
+	}{
+		// Tile 5 is bolted in the center, so the top row is built around it.
+		{"locked center", []int{4, 1, 2, 7, 5, 3, 8, 9, 6}, top, SolveOptions{Locked: lockedMask(9, 4)}, nil},
+		{"locked center required", []int{4, 1, 2, 7, 5, 3, 8, 9, 6}, PartialGoal{Cells: []int{1, 2, 3, w, 5, w, w, w, w}}, SolveOptions{Locked: lockedMask(9, 4)}, nil},
+		// Solvable on the whole board, but not by rotating the tiles around the bolted 5.
+		{"unsolvable around a locked center", []int{2, 3, 1, 4, 5, 6, 7, 8, 9}, PartialGoal{Cells: StandardGoal(3, 3)}, SolveOptions{Locked: lockedMask(9, 4)}, ErrUnsolvable},
+		{"locked blank", []int{1, 2, 3, 4, 9, 6, 7, 8, 5}, top, SolveOptions{Locked: lockedMask(9, 4)}, ErrLockedBlank},
+		{"locked tile required elsewhere", []int{4, 1, 2, 7, 5, 3, 8, 9, 6}, PartialGoal{Cells: []int{5, w, w, w, w, w, w, w, w}}, SolveOptions{Locked: lockedMask(9, 4)}, ErrLockedTile},
+		{"short mask", []int{4, 1, 2, 7, 5, 3, 8, 9, 6}, top, SolveOptions{Locked: lockedMask(4, 0)}, ErrLockedMask},
+		{"wrap", []int{3, 1, 2, 4, 5, 6, 7, 8, 9}, top, SolveOptions{Wrap: Torus}, ErrUnsupportedOption},
+		{"multi-tile metric", []int{3, 1, 2, 4, 5, 6, 7, 8, 9}, top, SolveOptions{Metric: MultiTile}, ErrUnsupportedOption},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := SolvePartial(context.Background(), tt.start, tt.goal, 3, 3, tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SolvePartial() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if tt.wantErr == ErrUnsolvable && stageDistance(t, tt.start, tt.goal, tt.opts.Locked, 3, 3) != -1 {
+					t.Error("SolvePartial() reports an unsolvable goal that can be reached")
+				}
+				return
+			}
+			end := playPath(tt.start, path)
+			for c, l := range tt.opts.Locked {
+				if l && end[c] != tt.start[c] {
+					t.Errorf("SolvePartial() moved locked tile %d", tt.start[c])
+				}
+			}
+			if ok, _ := tt.goal.Matches(end, 3, 3); !ok {
+				t.Errorf("SolvePartial() path ends at %v, which does not match the goal", end)
+			}
+			if want := stageDistance(t, tt.start, tt.goal, tt.opts.Locked, 3, 3); len(path)-1 != want {
+				t.Errorf("SolvePartial() takes %d moves, want %d", len(path)-1, want)
+			}
+		})
+	}
+}
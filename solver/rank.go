@@ -0,0 +1,333 @@
+package solver
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidRank        = errors.New("rank out of range")
+	ErrInvalidPermutation = errors.New("invalid permutation")
+)
+
+// MaxRankCells is the largest number of values, or board cells, that the rankings
+// support: 20! is the largest factorial that fits in an int.
+const MaxRankCells = 20
+
+// RankPermutation returns the lexicographic rank of perm among the permutations of
+// 0..len(perm)-1, in O(n log n). perm must be such a permutation with at most
+// MaxRankCells elements, so that the rank fits in an int; otherwise
+// ErrInvalidPermutation is returned.
+//
+// Example:
+//
+//	RankPermutation([]int{0, 1, 2}) // 0
+//	RankPermutation([]int{2, 1, 0}) // 5
+func RankPermutation(perm []int) (int, error) {
+	return RankPartialPermutation(perm, len(perm))
+}
+
+// UnrankPermutation returns the permutation of 0..n-1 with the given lexicographic rank.
+// It is the inverse of RankPermutation; rank must be less than n!, or ErrInvalidRank is
+// returned.
+func UnrankPermutation(rank, n int) ([]int, error) {
+	return UnrankPartialPermutation(rank, n, n)
+}
+
+// RankPartialPermutation returns the lexicographic rank of seq among the sequences of
+// len(seq) distinct values from 0..n-1, in O(n + k log n) for k = len(seq). Placing k
+// tiles on n cells is such a sequence, seq[i] being the cell of tile i. There are
+// n!/(n-k)! sequences. n must be at most MaxRankCells and seq such a sequence;
+// otherwise ErrInvalidPermutation is returned.
+//
+// Example:
+//
+//	RankPartialPermutation([]int{3, 0}, 4) // 9: the 10th of the 12 placements of 2 tiles on 4 cells
+func RankPartialPermutation(seq []int, n int) (int, error) {
+	if n < 0 || n > MaxRankCells || len(seq) > n {
+		return 0, fmt.Errorf("%w: %d values from 0 to %d", ErrInvalidPermutation, len(seq), n-1)
+	}
+	used := make([]bool, n)
+	for _, v := range seq {
+		if v < 0 || v >= n || used[v] {
+			return 0, fmt.Errorf("%w: %v holds %d more than once or outside 0 to %d", ErrInvalidPermutation, seq, v, n-1)
+		}
+		used[v] = true
+	}
+	return rankPartialPermutation(seq, n), nil
+}
+
+// UnrankPartialPermutation returns the sequence of k distinct values from 0..n-1 with
+// the given lexicographic rank. It is the inverse of RankPartialPermutation; n must be
+// at most MaxRankCells and k at most n, or ErrInvalidPermutation is returned, and rank
+// must be less than n!/(n-k)!, or ErrInvalidRank is returned.
+func UnrankPartialPermutation(rank, k, n int) ([]int, error) {
+	if n < 0 || n > MaxRankCells || k < 0 || k > n {
+		return nil, fmt.Errorf("%w: %d values from 0 to %d", ErrInvalidPermutation, k, n-1)
+	}
+	if rank < 0 || rank >= factorial(n)/factorial(n-k) {
+		return nil, ErrInvalidRank
+	}
+	return unrankPartialPermutation(rank, k, n), nil
+}
+
+// rankPartialPermutation is RankPartialPermutation without the checks of its input.
+func rankPartialPermutation(seq []int, n int) int {
+	free := newFreeSet(n)
+	rank := 0
+	for i, v := range seq {
+		rank = rank*(n-i) + free.count(v)
+		free.remove(v)
+	}
+	return rank
+}
+
+// unrankPartialPermutation is UnrankPartialPermutation without the checks of its input.
+func unrankPartialPermutation(rank, k, n int) []int {
+	digits := make([]int, k)
+	for i := k - 1; i >= 0; i-- {
+		digits[i] = rank % (n - i)
+		rank /= n - i
+	}
+	free := newFreeSet(n)
+	seq := make([]int, k)
+	for i, d := range digits {
+		seq[i] = free.find(d)
+		free.remove(seq[i])
+	}
+	return seq
+}
+
+// SolvableStates returns the number of boards that can reach a given goal, which is
+// the range of RankSolvable: half of the (rows*cols)! arrangements. Boards of more than
+// MaxRankCells cells return ErrInvalidSize.
+func SolvableStates(rows, cols int) (int, error) {
+	if err := validateRankShape(rows, cols); err != nil {
+		return 0, err
+	}
+	return solvableStates(rows * cols), nil
+}
+
+// solvableStates is SolvableStates for a board of n cells, at most MaxRankCells.
+func solvableStates(n int) int {
+	return factorial(n) / 2
+}
+
+// validateRankShape checks that a rows x cols board is valid and small enough to rank.
+func validateRankShape(rows, cols int) error {
+	if err := validateShape(rows, cols); err != nil {
+		return err
+	}
+	if rows*cols > MaxRankCells {
+		return fmt.Errorf("%w: %dx%d has more than %d cells to rank", ErrInvalidSize, rows, cols, MaxRankCells)
+	}
+	return nil
+}
+
+// RankSolvable returns a rank in [0, SolvableStates(rows, cols)) for a board that is
+// solvable towards goal, so that the solvable boards are numbered without gaps.
+// Boards are ordered by the position of the blank first; the order of the last two
+// tiles is implied by the parity and not encoded. Boards of more than MaxRankCells
+// cells return ErrInvalidSize.
+//
+// Example:
+//
+//	goal := StandardGoal(3, 3)
+//	r, err := RankSolvable([]int{1, 8, 2, 4, 3, 5, 7, 6, 9}, goal, 3, 3)
+//	board, err := UnrankSolvable(r, goal, 3, 3) // the same board
+func RankSolvable(board, goal []int, rows, cols int) (int, error) {
+	if err := validateRankShape(rows, cols); err != nil {
+		return 0, err
+	}
+	if err := validate(board, rows, cols); err != nil {
+		return 0, err
+	}
+	if err := validate(goal, rows, cols); err != nil {
+		return 0, err
+	}
+	if !isSolvable(board, goal, rows, cols) {
+		return 0, ErrUnsolvable
+	}
+	return newSolvableRanker(goal, rows, cols).rank(board), nil
+}
+
+// UnrankSolvable returns the solvable board with the given rank. It is the inverse of RankSolvable.
+func UnrankSolvable(rank int, goal []int, rows, cols int) ([]int, error) {
+	if err := validateRankShape(rows, cols); err != nil {
+		return nil, err
+	}
+	if err := validate(goal, rows, cols); err != nil {
+		return nil, err
+	}
+	if rank < 0 || rank >= solvableStates(rows*cols) {
+		return nil, ErrInvalidRank
+	}
+	board := make([]int, len(goal))
+	newSolvableRanker(goal, rows, cols).unrank(rank, board)
+	return board, nil
+}
+
+// solvableRanker ranks the boards solvable towards one goal.
+// A board is described by the cell of its blank and by the order of the remaining tiles
+// in the other cells, given as goal indices with the blank's index left out
+// ("compressed"). For a fixed blank cell, exactly the compressed orders of one parity
+// are solvable, so half of them need to be numbered.
+type solvableRanker struct {
+	goal      []int
+	goalIndex []int // goalIndex[v] is the cell of tile v in the goal
+	goalBlank int   // cell of the blank in the goal
+	half      int   // solvable orders per blank cell
+	parities  []int // parities[b] is the parity of the solvable orders with the blank at b
+}
+
+func newSolvableRanker(goal []int, rows, cols int) *solvableRanker {
+	n := len(goal)
+	r := &solvableRanker{goal: goal, goalIndex: make([]int, n+1), half: factorial(n-1) / 2, parities: make([]int, n)}
+	for i, v := range goal {
+		r.goalIndex[v] = i
+	}
+	r.goalBlank = r.goalIndex[n]
+
+	// Tiles in goal order around a blank at b have the identity as compressed order,
+	// so the solvable parity is even exactly when that board is solvable.
+	board := make([]int, n)
+	for b := range n {
+		next := 0
+		for cell := range board {
+			if cell == b {
+				board[cell] = n
+				continue
+			}
+			if next == r.goalBlank {
+				next++
+			}
+			board[cell] = goal[next]
+			next++
+		}
+		if !isSolvable(board, goal, rows, cols) {
+			r.parities[b] = 1
+		}
+	}
+	return r
+}
+
+// rank returns the rank of a solvable board.
+func (r *solvableRanker) rank(board []int) int {
+	n := len(board)
+	compressed := make([]int, 0, n-1)
+	blank := 0
+	for cell, v := range board {
+		if v == n {
+			blank = cell
+			continue
+		}
+		gi := r.goalIndex[v]
+		if gi > r.goalBlank {
+			gi--
+		}
+		compressed = append(compressed, gi)
+	}
+	return blank*r.half + rankPartialPermutation(compressed[:n-3], n-1)
+}
+
+// unrank writes the solvable board with the given rank to board.
+func (r *solvableRanker) unrank(rank int, board []int) {
+	n := len(board)
+	blank := rank / r.half
+	compressed := unrankPartialPermutation(rank%r.half, n-3, n-1)
+	// Append the values left out, then fix the order of the last two by the parity.
+	used := make([]bool, n-1)
+	for _, v := range compressed {
+		used[v] = true
+	}
+	for v, u := range used {
+		if !u {
+			compressed = append(compressed, v)
+		}
+	}
+	if permutationParity(compressed) != r.parities[blank] {
+		compressed[n-3], compressed[n-2] = compressed[n-2], compressed[n-3]
+	}
+
+	next := 0
+	for cell := range board {
+		if cell == blank {
+			board[cell] = n
+			continue
+		}
+		gi := compressed[next]
+		if gi >= r.goalBlank {
+			gi++
+		}
+		board[cell] = r.goal[gi]
+		next++
+	}
+}
+
+// permutationParity returns 0 for even and 1 for odd permutations of 0..len(perm)-1.
+func permutationParity(perm []int) int {
+	seen := make([]bool, len(perm))
+	cycles := 0
+	for i := range perm {
+		if seen[i] {
+			continue
+		}
+		cycles++
+		for j := i; !seen[j]; j = perm[j] {
+			seen[j] = true
+		}
+	}
+	return (len(perm) - cycles) % 2
+}
+
+// freeSet is a Fenwick tree over 0..n-1 counting the values not used yet.
+type freeSet []int
+
+// newFreeSet returns a set holding every value of 0..n-1, built in O(n).
+func newFreeSet(n int) freeSet {
+	f := make(freeSet, n+1)
+	for i := 1; i <= n; i++ {
+		f[i]++
+		if j := i + i&-i; j <= n {
+			f[j] += f[i]
+		}
+	}
+	return f
+}
+
+// remove takes v out of the set.
+func (f freeSet) remove(v int) {
+	for i := v + 1; i < len(f); i += i & -i {
+		f[i]--
+	}
+}
+
+// count returns the number of values in the set below v.
+func (f freeSet) count(v int) int {
+	c := 0
+	for i := v; i > 0; i -= i & -i {
+		c += f[i]
+	}
+	return c
+}
+
+// find returns the value in the set with exactly k smaller values in the set.
+func (f freeSet) find(k int) int {
+	pos := 0
+	for step := highestPowerOfTwo(len(f) - 1); step > 0; step >>= 1 {
+		if next := pos + step; next < len(f) && f[next] <= k {
+			pos = next
+			k -= f[next]
+		}
+	}
+	return pos
+}
+
+// highestPowerOfTwo returns the largest power of two not above n, or 0 for n < 1.
+func highestPowerOfTwo(n int) int {
+	p := 0
+	for q := 1; q <= n; q <<= 1 {
+		p = q
+	}
+	return p
+}
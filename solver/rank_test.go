@@ -0,0 +1,134 @@
+package solver
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// sequences returns every sequence of k distinct values from 0..n-1 in lexicographic order.
+func sequences(k, n int) [][]int {
+	if k == 0 {
+		return [][]int{{}}
+	}
+	var all [][]int
+	for _, prefix := range sequences(k-1, n) {
+		for v := range n {
+			if !slices.Contains(prefix, v) {
+				all = append(all, append(slices.Clone(prefix), v))
+			}
+		}
+	}
+	return all
+}
+
+func TestRankPermutation(t *testing.T) {
+	for n := range 7 {
+		for want, perm := range sequences(n, n) {
+			if got, err := RankPermutation(perm); got != want || err != nil {
+				t.Errorf("RankPermutation(%v) = %d, %v, want %d", perm, got, err, want)
+			}
+			if got, err := UnrankPermutation(want, n); !slices.Equal(got, perm) || err != nil {
+				t.Errorf("UnrankPermutation(%d, %d) = %v, %v, want %v", want, n, got, err, perm)
+			}
+		}
+	}
+}
+
+func TestRankPartialPermutation(t *testing.T) {
+	for _, n := range []int{1, 4, 6} {
+		for k := 0; k <= n; k++ {
+			all := sequences(k, n)
+			if len(all) != factorial(n)/factorial(n-k) {
+				t.Fatalf("brute force found %d sequences of %d values from %d", len(all), k, n)
+			}
+			for want, seq := range all {
+				if got, err := RankPartialPermutation(seq, n); got != want || err != nil {
+					t.Errorf("RankPartialPermutation(%v, %d) = %d, %v, want %d", seq, n, got, err, want)
+				}
+				if got, err := UnrankPartialPermutation(want, k, n); !slices.Equal(got, seq) || err != nil {
+					t.Errorf("UnrankPartialPermutation(%d, %d, %d) = %v, %v, want %v", want, k, n, got, err, seq)
+				}
+			}
+		}
+	}
+}
+
+func TestRankSolvable(t *testing.T) {
+	tests := []struct {
+		name string
+		goal []int
+		rows int
+		cols int
+	}{
+		{"2x2", StandardGoal(2, 2), 2, 2},
+		{"2x3", StandardGoal(2, 3), 2, 3},
+		{"3x2 custom goal", []int{6, 1, 2, 3, 5, 4}, 3, 2},
+		{"2x4 blank inside", []int{1, 2, 3, 4, 5, 8, 6, 7}, 2, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := tt.rows * tt.cols
+			states, err := SolvableStates(tt.rows, tt.cols)
+			if err != nil {
+				t.Fatalf("SolvableStates() error = %v", err)
+			}
+			seen := make([]bool, states)
+			for _, perm := range sequences(n, n) {
+				board := make([]int, n)
+				for i, v := range perm {
+					board[i] = v + 1
+				}
+				rank, err := RankSolvable(board, tt.goal, tt.rows, tt.cols)
+				if !isSolvable(board, tt.goal, tt.rows, tt.cols) {
+					if err != ErrUnsolvable {
+						t.Errorf("RankSolvable(%v) error = %v, want %v", board, err, ErrUnsolvable)
+					}
+					continue
+				}
+				if err != nil || rank < 0 || rank >= len(seen) || seen[rank] {
+					t.Fatalf("RankSolvable(%v) = %d, %v: out of range or repeated", board, rank, err)
+				}
+				seen[rank] = true
+				if got, err := UnrankSolvable(rank, tt.goal, tt.rows, tt.cols); err != nil || !slices.Equal(got, board) {
+					t.Errorf("UnrankSolvable(%d) = %v, %v, want %v", rank, got, err, board)
+				}
+			}
+			if slices.Contains(seen, false) {
+				t.Errorf("some of the %d ranks are not used", len(seen))
+			}
+		})
+	}
+}
+
+func TestRank_Errors(t *testing.T) {
+	goal5x5 := StandardGoal(5, 5)
+	tests := []struct {
+		name    string
+		rank    func() error
+		wantErr error
+	}{
+		{"permutation with a repeated value", func() error { _, err := RankPermutation([]int{0, 0, 2}); return err }, ErrInvalidPermutation},
+		{"permutation with a value out of range", func() error { _, err := RankPermutation([]int{0, 3, 1}); return err }, ErrInvalidPermutation},
+		{"permutation of 21 values", func() error { _, err := RankPermutation(make([]int, 21)); return err }, ErrInvalidPermutation},
+		{"rank of n! or more", func() error { _, err := UnrankPermutation(6, 3); return err }, ErrInvalidRank},
+		{"negative rank", func() error { _, err := UnrankPermutation(-1, 3); return err }, ErrInvalidRank},
+		{"unrank 21 values", func() error { _, err := UnrankPermutation(0, 21); return err }, ErrInvalidPermutation},
+		{"sequence longer than n", func() error { _, err := RankPartialPermutation([]int{0, 1, 2}, 2); return err }, ErrInvalidPermutation},
+		{"sequence with a negative value", func() error { _, err := RankPartialPermutation([]int{-1}, 4); return err }, ErrInvalidPermutation},
+		{"partial rank out of range", func() error { _, err := UnrankPartialPermutation(12, 2, 4); return err }, ErrInvalidRank},
+		{"more values than cells", func() error { _, err := UnrankPartialPermutation(0, 5, 4); return err }, ErrInvalidPermutation},
+		{"solvable states of 5x5", func() error { _, err := SolvableStates(5, 5); return err }, ErrInvalidSize},
+		{"solvable states of -1x3", func() error { _, err := SolvableStates(-1, 3); return err }, ErrInvalidSize},
+		{"rank a 5x5 board", func() error { _, err := RankSolvable(goal5x5, goal5x5, 5, 5); return err }, ErrInvalidSize},
+		{"unrank a 5x5 board", func() error { _, err := UnrankSolvable(0, goal5x5, 5, 5); return err }, ErrInvalidSize},
+		{"solvable rank out of range", func() error { _, err := UnrankSolvable(12, StandardGoal(2, 2), 2, 2); return err }, ErrInvalidRank},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rank(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
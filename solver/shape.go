@@ -0,0 +1,154 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidShape = errors.New("invalid board shape")
+
+// Shape is a board made of some of the cells of a rows x cols rectangle, such as a
+// cross, an L or a board with holes punched in it. Tiles move between active cells
+// that share an edge.
+//
+// Boards on a shape list the tiles of the active cells only, row by row: with n active
+// cells they hold the numbers from 1 to n, and n is the blank. Cell indices, as in the
+// paths returned by SolveShape, count active cells in the same order.
+type Shape struct {
+	Rows int `json:"rows"`
+	Cols int `json:"cols"`
+	// Active has one entry per cell of the rectangle, row by row.
+	Active []bool `json:"active"`
+}
+
+// ParseShape returns the shape drawn by lines, one per row, with '#' for active cells
+// and '.' for the cells left out.
+//
+// Example:
+//
+//	cross, err := ParseShape(".#.", "###", ".#.") // 5 active cells; the blank is 5
+func ParseShape(lines ...string) (Shape, error) {
+	if len(lines) == 0 {
+		return Shape{}, fmt.Errorf("%w: no rows", ErrInvalidShape)
+	}
+	s := Shape{Rows: len(lines), Cols: len(lines[0])}
+	for r, line := range lines {
+		if len(line) != s.Cols {
+			return Shape{}, fmt.Errorf("%w: row %d has %d cells, want %d", ErrInvalidShape, r+1, len(line), s.Cols)
+		}
+		for _, c := range line {
+			switch c {
+			case '#':
+				s.Active = append(s.Active, true)
+			case '.':
+				s.Active = append(s.Active, false)
+			default:
+				return Shape{}, fmt.Errorf("%w: unexpected %q in row %d", ErrInvalidShape, c, r+1)
+			}
+		}
+	}
+	return s, s.validate()
+}
+
+// String draws the shape in the format read by ParseShape, with rows separated by newlines.
+func (s Shape) String() string {
+	var b strings.Builder
+	for i, active := range s.Active {
+		if i > 0 && s.Cols > 0 && i%s.Cols == 0 {
+			b.WriteByte('\n')
+		}
+		if active {
+			b.WriteByte('#')
+		} else {
+			b.WriteByte('.')
+		}
+	}
+	return b.String()
+}
+
+// Cells returns the rectangle index, row*Cols+col, of each active cell in board order.
+//
+// Example:
+//
+//	cross.Cells() // [1 3 4 5 7]
+func (s Shape) Cells() []int {
+	var cells []int
+	for i, active := range s.Active {
+		if active {
+			cells = append(cells, i)
+		}
+	}
+	return cells
+}
+
+// validate checks that the shape covers its rectangle and has room for a tile and the blank.
+func (s Shape) validate() error {
+	if s.Rows < 1 || s.Cols < 1 || len(s.Active) != s.Rows*s.Cols {
+		return fmt.Errorf("%w: %d cells for %d rows and %d columns", ErrInvalidShape, len(s.Active), s.Rows, s.Cols)
+	}
+	if len(s.Cells()) < 2 {
+		return fmt.Errorf("%w: fewer than 2 active cells", ErrInvalidShape)
+	}
+	return nil
+}
+
+// Graph returns the graph of the shape, whose vertices are its active cells in board
+// order and whose edges join cells that share a side.
+//
+// Example:
+//
+//	cross.Graph().Adj // [[2] [2] [0 4 1 3] [2] [2]]
+func (s Shape) Graph() Graph {
+	cells := s.Cells()
+	index := make([]int, len(s.Active))
+	inactive := make([]bool, len(s.Active))
+	for i, active := range s.Active {
+		inactive[i] = !active
+	}
+	for i, c := range cells {
+		index[c] = i
+	}
+	full := gridGraph(s.Rows, s.Cols, inactive)
+	g := Graph{Adj: make([][]int, len(cells))}
+	for i, c := range cells {
+		for _, w := range full.adj[c] {
+			g.Adj[i] = append(g.Adj[i], index[w])
+		}
+	}
+	return g
+}
+
+// ShapeSolvable reports whether start can reach goal on the shape. The decision is exact
+// for every shape, including shapes in several pieces and shapes whose cells are not
+// 2-connected, such as rings and corridors, where the parity of the permutation is not
+// enough.
+//
+// Example:
+//
+//	// Tiles on a ring can only rotate.
+//	ring, _ := ParseShape("###", "#.#", "###")
+//	ok, err := ShapeSolvable([]int{2, 1, 3, 4, 5, 6, 7, 8}, StandardGoal(1, 8), ring) // false
+func ShapeSolvable(start, goal []int, s Shape) (bool, error) {
+	if err := s.validate(); err != nil {
+		return false, err
+	}
+	return GraphSolvable(start, goal, s.Graph())
+}
+
+// SolveShape finds a shortest solution of a puzzle on a shape with SolveGraph. It returns
+// the cells the blank visits, including its initial cell, as active cell indices, and
+// estimates the moves left by the tiles' shortest-path distances within the shape.
+// opts.Locked, if set, has one entry per active cell.
+//
+// Example:
+//
+//	cross, _ := ParseShape(".#.", "###", ".#.")
+//	path, err := SolveShape(ctx, []int{1, 2, 5, 4, 3}, StandardGoal(1, 5), cross, SolveOptions{}) // [2 4]
+func SolveShape(ctx context.Context, start, goal []int, s Shape, opts SolveOptions) ([]int, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return SolveGraph(ctx, start, goal, s.Graph(), opts)
+}
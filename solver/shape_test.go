@@ -0,0 +1,189 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// mustParseShape returns the shape drawn by lines, failing the test if it is invalid.
+func mustParseShape(t *testing.T, lines ...string) Shape {
+	t.Helper()
+	s, err := ParseShape(lines...)
+	if err != nil {
+		t.Fatalf("ParseShape(%q) error = %v", lines, err)
+	}
+	return s
+}
+
+func TestParseShape(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		want    Shape
+		wantErr bool
+	}{
+		{"cross", []string{".#.", "###", ".#."}, Shape{Rows: 3, Cols: 3, Active: []bool{false, true, false, true, true, true, false, true, false}}, false},
+		{"single row", []string{"##"}, Shape{Rows: 1, Cols: 2, Active: []bool{true, true}}, false},
+		{"no rows", nil, Shape{}, true},
+		{"ragged", []string{"##", "#"}, Shape{}, true},
+		{"unknown cell", []string{"#x"}, Shape{}, true},
+		{"one cell", []string{"#.", ".."}, Shape{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseShape(tt.lines...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseShape() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidShape) {
+					t.Errorf("ParseShape() error = %v, want ErrInvalidShape", err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseShape() = %+v, want %+v", got, tt.want)
+			}
+			if s, _ := ParseShape(tt.lines...); s.String() != joinLines(tt.lines) {
+				t.Errorf("String() = %q, want %q", s.String(), joinLines(tt.lines))
+			}
+		})
+	}
+}
+
+// joinLines joins lines with newlines.
+func joinLines(lines []string) string {
+	s := ""
+	for i, l := range lines {
+		if i > 0 {
+			s += "\n"
+		}
+		s += l
+	}
+	return s
+}
+
+func TestShape_Cells(t *testing.T) {
+	cross := mustParseShape(t, ".#.", "###", ".#.")
+	if got, want := cross.Cells(), []int{1, 3, 4, 5, 7}; !slices.Equal(got, want) {
+		t.Errorf("Cells() = %v, want %v", got, want)
+	}
+}
+
+func TestShape_Graph(t *testing.T) {
+	// 0 1 .
+	// 2 3 4
+	l := mustParseShape(t, "##.", "###")
+	want := [][]int{{2, 1}, {3, 0}, {0, 3}, {1, 2, 4}, {3}}
+	if got := l.Graph().Adj; !reflect.DeepEqual(got, want) {
+		t.Errorf("Graph() = %v, want %v", got, want)
+	}
+	want = [][]int{{2, 1}, {0}, {0}, nil, nil}
+	if got := l.Graph().graph(lockedMask(5, 3)).adj; !reflect.DeepEqual(got, want) {
+		t.Errorf("graph(locked) = %v, want %v", got, want)
+	}
+}
+
+func TestShapeSolvable_Exhaustive(t *testing.T) {
+	shapes := map[string][]string{
+		"cross":              {".#.", "###", ".#."},
+		"ring":               {"###", "#.#", "###"},
+		"L":                  {"#..", "#..", "###"},
+		"square with a tail": {"##..", "####"},
+		"hole":               {"###.", "#.##", "###."},
+		"two pieces":         {"##.#", "##.#"},
+	}
+	for name, lines := range shapes {
+		s := mustParseShape(t, lines...)
+		if n := len(s.Cells()); n > 9 {
+			t.Fatalf("%s: %d cells is too many to check exhaustively", name, n)
+		}
+		checkSolvable(t, name, s.Graph().graph(nil), StandardGoal(1, len(s.Cells())))
+	}
+}
+
+func TestShapeSolvable(t *testing.T) {
+	ring := mustParseShape(t, "###", "#.#", "###")
+	tests := []struct {
+		name    string
+		start   []int
+		shape   Shape
+		want    bool
+		wantErr error
+	}{
+		{"ring rotation", []int{2, 3, 8, 1, 5, 4, 6, 7}, ring, true, nil},
+		{"ring swap", []int{2, 1, 3, 4, 5, 6, 7, 8}, ring, false, nil},
+		{"wrong length", []int{1, 2, 3}, ring, false, ErrSizeMismatch},
+		{"invalid shape", []int{1, 2}, Shape{Rows: 1, Cols: 3, Active: []bool{true, true}}, false, ErrInvalidShape},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goal := StandardGoal(1, max(len(tt.start), 2))
+			got, err := ShapeSolvable(tt.start, goal, tt.shape)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("ShapeSolvable() = %v, %v; want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSolveShape(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		start   []int
+		locked  []bool
+		wantErr error
+	}{
+		{"cross", []string{".#.", "###", ".#."}, []int{1, 2, 5, 4, 3}, nil, nil},
+		{"ring", []string{"###", "#.#", "###"}, []int{2, 3, 8, 1, 5, 4, 6, 7}, nil, nil},
+		{"hole in a 3x4", []string{"####", "#.##", "####"}, []int{5, 1, 2, 4, 8, 3, 6, 9, 11, 10, 7}, nil, nil},
+		{"L", []string{"##..", "####", "##.."}, []int{2, 7, 1, 5, 8, 6, 4, 3}, nil, nil},
+		{"locked", []string{"####", "####"}, []int{1, 2, 7, 3, 5, 6, 4, 8}, lockedMask(8, 1), nil},
+		{"ring swap", []string{"###", "#.#", "###"}, []int{2, 1, 3, 4, 5, 6, 7, 8}, nil, ErrUnsolvable},
+		{"wrong length", []string{"##", "##"}, []int{1, 2, 3}, nil, ErrSizeMismatch},
+		{"locked blank", []string{"###"}, []int{1, 3, 2}, lockedMask(3, 1), ErrLockedBlank},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := mustParseShape(t, tt.lines...)
+			goal := StandardGoal(1, len(s.Cells()))
+			path, err := SolveShape(context.Background(), tt.start, goal, s, SolveOptions{Locked: tt.locked})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SolveShape() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			g := s.Graph().graph(tt.locked)
+			board := slices.Clone(tt.start)
+			for i := 1; i < len(path); i++ {
+				if !slices.Contains(g.adj[path[i-1]], path[i]) {
+					t.Fatalf("path %v moves the blank from %d to %d, which are not neighbors", path, path[i-1], path[i])
+				}
+				board[path[i-1]], board[path[i]] = board[path[i]], board[path[i-1]]
+			}
+			if !slices.Equal(board, goal) {
+				t.Fatalf("path %v ends at %v", path, board)
+			}
+			if want := lockedDistance(g, tt.start, goal); len(path)-1 != want {
+				t.Errorf("path takes %d moves, want %d", len(path)-1, want)
+			}
+		})
+	}
+}
+
+func TestSolveShape_Heuristic(t *testing.T) {
+	// Tiles 2 and 7 have to go around the hole: their distances are 4, while their
+	// Manhattan distances are 2.
+	ring := mustParseShape(t, "###", "#.#", "###")
+	g := ring.Graph().graph(nil)
+	target := newGraphGoal(StandardGoal(1, 8), g)
+	board := []int{1, 7, 3, 4, 5, 6, 2, 8}
+	if h := target.heuristic(board); h != 8 {
+		t.Errorf("heuristic(%v) = %d, want 8", board, h)
+	}
+}
@@ -0,0 +1,127 @@
+package solver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Solvability explains whether a start configuration can reach a goal configuration.
+//
+// Every move swaps the blank with a neighbor, changing the permutation parity and the
+// parity of the blank's Manhattan displacement together. The start configuration can
+// reach the goal if and only if
+//
+//	(StartInversions + BlankDistance + GoalInversions) % 2 == 0
+//
+// where the blank counts as the largest tile when counting inversions.
+type Solvability struct {
+	Solvable        bool `json:"solvable"`
+	StartInversions int  `json:"start_inversions"`
+	GoalInversions  int  `json:"goal_inversions"`
+	// BlankDistance is the Manhattan distance between the blank's start and goal cells.
+	BlankDistance int `json:"blank_distance"`
+	// Parity is (StartInversions + BlankDistance + GoalInversions) % 2.
+	Parity int `json:"parity"`
+	// Swap suggests two tiles whose exchange in the start configuration makes the puzzle
+	// solvable. It is nil when the puzzle is already solvable.
+	Swap *Swap `json:"swap,omitempty"`
+}
+
+// Swap identifies two cells of a board whose tiles are to be exchanged.
+type Swap struct {
+	I     int `json:"i"`      // index of the first cell
+	J     int `json:"j"`      // index of the second cell
+	TileI int `json:"tile_i"` // tile at I before the swap
+	TileJ int `json:"tile_j"` // tile at J before the swap
+}
+
+// CheckSolvability reports whether start can be solved towards goal, and why.
+// For unsolvable puzzles it suggests the swap of two non-blank tiles that leaves the
+// start closest to the goal by the solver's heuristic; any such swap flips the parity.
+//
+// Example:
+//
+//	s, err := CheckSolvability([]int{2, 1, 3, 4}, []int{1, 2, 3, 4}, 2, 2)
+//	// s.Solvable == false, s.Swap == &Swap{I: 0, J: 1, TileI: 2, TileJ: 1}
+func CheckSolvability(start, goal []int, rows, cols int) (Solvability, error) {
+	if err := validate(start, rows, cols); err != nil {
+		return Solvability{}, err
+	}
+	if err := validate(goal, rows, cols); err != nil {
+		return Solvability{}, err
+	}
+
+	s := solvability(start, goal, rows, cols)
+	if !s.Solvable {
+		s.Swap = suggestSwap(start, goal, rows, cols)
+	}
+	return s, nil
+}
+
+// String explains the parity argument in a few lines of prose.
+func (s Solvability) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "start inversions: %d\n", s.StartInversions)
+	fmt.Fprintf(&b, "goal inversions:  %d\n", s.GoalInversions)
+	fmt.Fprintf(&b, "blank distance:   %d\n", s.BlankDistance)
+	fmt.Fprintf(&b, "parity:           (%d + %d + %d) %% 2 = %d\n", s.StartInversions, s.BlankDistance, s.GoalInversions, s.Parity)
+	if s.Solvable {
+		b.WriteString("The parity is even, so the puzzle is solvable.")
+	} else {
+		b.WriteString("The parity is odd, so the puzzle is unsolvable: every move preserves it.")
+	}
+	if s.Swap != nil {
+		fmt.Fprintf(&b, "\nSwapping tiles %d and %d (cells %d and %d) makes it solvable.", s.Swap.TileI, s.Swap.TileJ, s.Swap.I, s.Swap.J)
+	}
+	return b.String()
+}
+
+// solvability computes the parity argument without suggesting a swap.
+func solvability(start, goal []int, rows, cols int) Solvability {
+	blank := rows * cols
+	var startBlankIndex, goalBlankIndex int
+	for i, v := range start {
+		if v == blank {
+			startBlankIndex = i
+		}
+	}
+	for i, v := range goal {
+		if v == blank {
+			goalBlankIndex = i
+		}
+	}
+
+	s := Solvability{
+		StartInversions: inversionNumber(start),
+		GoalInversions:  inversionNumber(goal),
+		BlankDistance:   manhattanDistance(startBlankIndex, goalBlankIndex, rows, cols),
+	}
+	s.Parity = (s.StartInversions + s.BlankDistance + s.GoalInversions) % 2
+	s.Solvable = s.Parity == 0
+	return s
+}
+
+// suggestSwap returns the swap of two non-blank tiles in start with the lowest resulting heuristic.
+func suggestSwap(start, goal []int, rows, cols int) *Swap {
+	blank := rows * cols
+	board := make([]int, len(start))
+	copy(board, start)
+
+	var best *Swap
+	bestHeuristic := 0
+	for i := range board {
+		for j := i + 1; j < len(board); j++ {
+			if board[i] == blank || board[j] == blank {
+				continue
+			}
+			board[i], board[j] = board[j], board[i]
+			h := calculateHeuristic(board, goal, rows, cols)
+			board[i], board[j] = board[j], board[i]
+			if best == nil || h < bestHeuristic {
+				best = &Swap{I: i, J: j, TileI: board[i], TileJ: board[j]}
+				bestHeuristic = h
+			}
+		}
+	}
+	return best
+}
@@ -0,0 +1,96 @@
+package solver
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckSolvability(t *testing.T) {
+	tests := []struct {
+		name  string
+		start []int
+		goal  []int
+		rows  int
+		cols  int
+		want  Solvability
+	}{
+		{
+			name:  "solved",
+			start: []int{1, 2, 3, 4},
+			goal:  []int{1, 2, 3, 4},
+			rows:  2,
+			cols:  2,
+			want:  Solvability{Solvable: true},
+		},
+		{
+			name:  "blank moved",
+			start: []int{1, 2, 4, 3},
+			goal:  []int{1, 2, 3, 4},
+			rows:  2,
+			cols:  2,
+			want:  Solvability{Solvable: true, StartInversions: 1, BlankDistance: 1},
+		},
+		{
+			name:  "swapped tiles",
+			start: []int{2, 1, 3, 4},
+			goal:  []int{1, 2, 3, 4},
+			rows:  2,
+			cols:  2,
+			want: Solvability{
+				StartInversions: 1,
+				Parity:          1,
+				Swap:            &Swap{I: 0, J: 1, TileI: 2, TileJ: 1},
+			},
+		},
+		{
+			name:  "custom goal",
+			start: []int{1, 2, 3, 4, 5, 6, 8, 7, 9},
+			goal:  []int{9, 1, 2, 3, 4, 5, 6, 7, 8},
+			rows:  3,
+			cols:  3,
+			want: Solvability{
+				StartInversions: 1,
+				GoalInversions:  8,
+				BlankDistance:   4,
+				Parity:          1,
+				Swap:            &Swap{I: 5, J: 6, TileI: 6, TileJ: 8},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CheckSolvability(tt.start, tt.goal, tt.rows, tt.cols)
+			if err != nil {
+				t.Fatalf("CheckSolvability() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckSolvability() = %+v, want %+v", got, tt.want)
+			}
+			if got.Swap != nil {
+				board := append([]int(nil), tt.start...)
+				board[got.Swap.I], board[got.Swap.J] = board[got.Swap.J], board[got.Swap.I]
+				if !isSolvable(board, tt.goal, tt.rows, tt.cols) {
+					t.Errorf("suggested swap %+v does not make the puzzle solvable", got.Swap)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckSolvability_Invalid(t *testing.T) {
+	if _, err := CheckSolvability([]int{1, 2, 3}, []int{1, 2, 3, 4}, 2, 2); !errors.Is(err, ErrSizeMismatch) {
+		t.Errorf("CheckSolvability() error = %v, want %v", err, ErrSizeMismatch)
+	}
+}
+
+func TestSolvability_String(t *testing.T) {
+	s, _ := CheckSolvability([]int{2, 1, 3, 4}, []int{1, 2, 3, 4}, 2, 2)
+	got := s.String()
+	for _, want := range []string{"start inversions: 1", "unsolvable", "Swapping tiles 2 and 1"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("String() = %q, want it to contain %q", got, want)
+		}
+	}
+}
@@ -1,12 +1,17 @@
 package solver
 
 import (
+	"context"
 	"errors"
 	"math"
-	"slices"
 )
 
-var ErrUnsolvable = errors.New("puzzle is unsolvable")
+var (
+	ErrUnsolvable = errors.New("puzzle is unsolvable")
+	// ErrUnsupportedOption is returned for SolveOptions that an entry point cannot honor,
+	// rather than ignoring them.
+	ErrUnsupportedOption = errors.New("unsupported solve option")
+)
 
 // Solve solves the sliding puzzle and finds the shortest path from the start configuration to the goal configuration.
 // The blank tile is represented by the value rows*cols.
@@ -19,36 +24,118 @@ var ErrUnsolvable = errors.New("puzzle is unsolvable")
 //	goal := []int{1, 2, 3, 4}
 //	path, err := Solve(start, goal, 2, 2)
 func Solve(start, goal []int, rows, cols int) ([]int, error) {
+	return SolveContext(context.Background(), start, goal, rows, cols)
+}
+
+// SolveContext is like Solve but gives up with ctx.Err() once ctx is done.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+//	defer cancel()
+//	path, err := SolveContext(ctx, start, goal, 4, 4)
+func SolveContext(ctx context.Context, start, goal []int, rows, cols int) ([]int, error) {
+	return SolveWithOptions(ctx, start, goal, rows, cols, SolveOptions{})
+}
+
+// SolveOptions tunes SolveWithOptions. The zero value behaves like SolveContext.
+type SolveOptions struct {
+	// Stats, when non-nil, receives the statistics of the search, also when it fails.
+	Stats *Stats
+	// Progress, when non-nil, is called on the solving goroutine whenever IDA* starts
+	// a new threshold and every progressInterval expansions in between.
+	Progress func(Progress)
+	// Cache, when non-nil, is consulted before searching and remembers new solutions.
+	Cache *Cache
+	// Locked, when non-nil, marks cells whose tiles can never move, such as walls or
+	// bolted tiles. It has one entry per cell; locked cells must hold the same tile in
+	// start and goal and may not hold the blank. Solvability is then decided on the
+	// graph of free cells, and tablebases and Cache are not used.
+	Locked []bool
+	// Wrap lets moves cross the edges of the board, on a cylinder or a torus. Solvability
+	// follows WrapSolvable, paths may step from one edge to the opposite one, and
+	// tablebases and Cache are not used.
+	Wrap Wrap
+	// Metric selects what counts as one move. With MultiTile, shortest solutions slide
+	// whole segments of a row or column at once; the path still lists every cell the blank
+	// visits, Segments groups it into moves, and tablebases and Cache are not used.
+	Metric Metric
+}
+
+// Progress is a snapshot of a running search.
+type Progress struct {
+	// Threshold is the current IDA* cost bound, a lower bound on the solution length.
+	Threshold     int `json:"threshold"`
+	NodesExpanded int `json:"nodes_expanded"`
+	Iterations    int `json:"iterations"`
+}
+
+// Stats reports the work done by a search.
+type Stats struct {
+	// NodesExpanded is the number of nodes whose children were generated.
+	NodesExpanded int `json:"nodes_expanded"`
+	// Iterations is the number of IDA* thresholds tried.
+	Iterations int `json:"iterations"`
+	// CacheHit is set when the solution was taken from SolveOptions.Cache without searching.
+	CacheHit bool `json:"cache_hit,omitempty"`
+	// TablebaseHit is set when the solution was read from a tablebase loaded with LoadTablebase.
+	TablebaseHit bool `json:"tablebase_hit,omitempty"`
+}
+
+// SolveWithOptions is like SolveContext with additional options.
+//
+// Example:
+//
+//	var stats Stats
+//	path, err := SolveWithOptions(ctx, start, goal, 3, 3, SolveOptions{Stats: &stats})
+func SolveWithOptions(ctx context.Context, start, goal []int, rows, cols int, opts SolveOptions) ([]int, error) {
 	if err := validate(start, rows, cols); err != nil {
 		return nil, err
 	}
 	if err := validate(goal, rows, cols); err != nil {
 		return nil, err
 	}
+	if opts.Metric == MultiTile {
+		return solveMultiTile(ctx, start, goal, rows, cols, opts)
+	}
+	if opts.Locked != nil || opts.Wrap != NoWrap {
+		return solveVariant(ctx, start, goal, rows, cols, opts)
+	}
 
 	if !isSolvable(start, goal, rows, cols) {
 		return nil, ErrUnsolvable
 	}
 
-	root := newNode(start, rows, cols)
-	threshold := calculateHeuristic(root.board, goal, rows, cols)
+	if t := loadedTablebase(goal, rows, cols); t != nil {
+		if opts.Stats != nil {
+			*opts.Stats = Stats{TablebaseHit: true}
+		}
+		return t.solve(start)
+	}
 
-	for {
-		nextThreshold, found := search(root, threshold, goal, rows, cols)
-		if found != nil {
-			var path []int
-			for n := found; n != nil; n = n.parent {
-				path = append(path, n.blankIdx)
+	if opts.Cache != nil {
+		if path, ok := opts.Cache.get(start, goal, rows, cols); ok {
+			if opts.Stats != nil {
+				*opts.Stats = Stats{CacheHit: true}
 			}
-			slices.Reverse(path)
 			return path, nil
 		}
+	}
 
-		if nextThreshold == math.MaxInt {
-			return nil, ErrUnsolvable
-		}
-		threshold = nextThreshold
+	s := &searcher{ctx: ctx, progress: opts.Progress, goal: goal, rows: rows, cols: cols}
+	found, err := s.run(newNode(start, rows, cols))
+	if opts.Stats != nil {
+		*opts.Stats = Stats{NodesExpanded: s.expanded, Iterations: s.iterations}
+	}
+	if err != nil {
+		return nil, err
+	}
+	path := found.path()
+	if opts.Cache != nil {
+		// Failing to store the solution does not make it wrong; the search is simply repeated next time.
+		_ = opts.Cache.put(start, goal, rows, cols, path)
 	}
+	return path, nil
 }
 
 // isSolvable checks if the puzzle configuration can be solved to reach the target state.
@@ -59,72 +146,169 @@ func Solve(start, goal []int, rows, cols int) ([]int, error) {
 //	goal := []int{1, 2, 3, 4}
 //	isSolvable(start, goal, 2, 2) // returns true
 func isSolvable(start, goal []int, rows, cols int) bool {
-	startInversionNumber := inversionNumber(start)
-	blank := rows * cols
-	var startBlankIndex, goalBlankIndex int
-	for i, v := range start {
-		if v == blank {
-			startBlankIndex = i
+	return solvability(start, goal, rows, cols).Solvable
+}
+
+// target is a goal other than a single configuration with the default heuristic.
+type target interface {
+	// heuristic returns an admissible estimate of the moves from board to the goal.
+	heuristic(board []int) int
+	// matches reports whether board is a goal configuration.
+	matches(board []int) bool
+}
+
+// searcher holds the state shared by the iterations of one IDA* run.
+type searcher struct {
+	ctx        context.Context // optional; the search is abandoned once it is done
+	err        error           // set when the search was abandoned
+	progress   func(Progress)  // optional
+	goal       []int
+	target     target // optional; replaces goal when set
+	rows       int
+	cols       int
+	expanded   int   // number of nodes whose children were generated
+	iterations int   // number of thresholds tried
+	threshold  int   // current threshold
+	plies      []int // optional; nodes within the bound at each depth, see countSolutions
+}
+
+// run deepens the threshold from the root's heuristic until the goal is found.
+// It returns the goal node, whose cost is the optimal number of moves.
+func (s *searcher) run(root *node) (*node, error) {
+	threshold := s.heuristic(root.board)
+	for {
+		s.iterations++
+		s.threshold = threshold
+		s.report()
+		nextThreshold, found := s.search(root, threshold)
+		if found != nil {
+			return found, nil
 		}
-	}
-	for i, v := range goal {
-		if v == blank {
-			goalBlankIndex = i
+		if s.err != nil {
+			return nil, s.err
 		}
+
+		if nextThreshold == math.MaxInt {
+			return nil, ErrUnsolvable
+		}
+		threshold = nextThreshold
+	}
+}
+
+// heuristic returns an admissible estimate of the moves from board to the goal.
+func (s *searcher) heuristic(board []int) int {
+	if s.target != nil {
+		return s.target.heuristic(board)
+	}
+	return calculateHeuristic(board, s.goal, s.rows, s.cols)
+}
+
+// reached reports whether n is a goal configuration.
+func (s *searcher) reached(n *node) bool {
+	if s.target != nil {
+		return s.target.matches(n.board)
 	}
-	manhattanDistance := manhattanDistance(startBlankIndex, goalBlankIndex, rows, cols)
-	goalInversionNumber := inversionNumber(goal)
-	return (startInversionNumber+manhattanDistance)%2 == goalInversionNumber%2
+	return n.has(s.goal)
 }
 
 // search performs the Depth-First Search for IDA*.
 // It returns the next threshold (min f-value exceeding current threshold) or the goal node.
-func search(currentNode *node, threshold int, goal []int, rows, cols int) (int, *node) {
-	heuristic := calculateHeuristic(currentNode.board, goal, rows, cols)
+func (s *searcher) search(currentNode *node, threshold int) (int, *node) {
+	heuristic := s.heuristic(currentNode.board)
 	estimatedTotalCost := currentNode.cost + heuristic
 
 	if estimatedTotalCost > threshold {
 		return estimatedTotalCost, nil
 	}
 
-	if currentNode.has(goal) {
+	if s.reached(currentNode) {
 		return estimatedTotalCost, currentNode
 	}
 
+	s.expanded++
+	if s.cancelled() {
+		return math.MaxInt, nil
+	}
 	minNextThreshold := math.MaxInt
 
-	processNeighbor := func(neighbor *node) (int, *node) {
+	for _, neighbor := range currentNode.children() {
 		if isCycle(neighbor) {
+			continue
+		}
+		res, found := s.search(neighbor, threshold)
+		if found != nil {
+			return res, found
+		}
+		if s.err != nil {
 			return math.MaxInt, nil
 		}
-		return search(neighbor, threshold, goal, rows, cols)
+		if res < minNextThreshold {
+			minNextThreshold = res
+		}
+	}
+
+	return minNextThreshold, nil
+}
+
+const (
+	// cancelCheckInterval is the number of expansions between checks of the searcher's context.
+	cancelCheckInterval = 1024
+	// progressInterval is the number of expansions between progress reports within an iteration.
+	progressInterval = 64 * cancelCheckInterval
+)
+
+// cancelled reports whether the search has been abandoned, recording the context's error.
+// The context is only consulted every cancelCheckInterval expansions; progress is reported
+// every progressInterval expansions.
+func (s *searcher) cancelled() bool {
+	if s.expanded%cancelCheckInterval == 0 {
+		if s.err == nil && s.ctx != nil {
+			s.err = s.ctx.Err()
+		}
+		if s.expanded%progressInterval == 0 {
+			s.report()
+		}
 	}
+	return s.err != nil
+}
 
-	var moves []func() *node
-	if currentNode.canMoveUp() {
-		moves = append(moves, currentNode.upNode)
+// report calls the progress callback, if any, with the current state of the search.
+func (s *searcher) report() {
+	if s.progress != nil {
+		s.progress(Progress{Threshold: s.threshold, NodesExpanded: s.expanded, Iterations: s.iterations})
 	}
-	if currentNode.canMoveDown() {
-		moves = append(moves, currentNode.downNode)
+}
+
+// countSolutions counts the paths from currentNode that reach the goal at exactly the given cost.
+// Called with the optimal cost, it returns the number of distinct optimal solutions.
+// When s.plies is set, it also counts the nodes within the bound at each depth.
+// It gives up with 0 once the search is abandoned; see s.err.
+func (s *searcher) countSolutions(currentNode *node, cost int) int {
+	if currentNode.cost+s.heuristic(currentNode.board) > cost {
+		return 0
 	}
-	if currentNode.canMoveLeft() {
-		moves = append(moves, currentNode.leftNode)
+	if s.plies != nil {
+		s.plies[currentNode.cost]++
 	}
-	if currentNode.canMoveRight() {
-		moves = append(moves, currentNode.rightNode)
+	if currentNode.cost == cost {
+		if s.reached(currentNode) {
+			return 1
+		}
+		return 0
 	}
 
-	for _, move := range moves {
-		res, found := processNeighbor(move())
-		if found != nil {
-			return res, found
-		}
-		if res < minNextThreshold {
-			minNextThreshold = res
+	s.expanded++
+	if s.cancelled() {
+		return 0
+	}
+	count := 0
+	for _, neighbor := range currentNode.children() {
+		if isCycle(neighbor) {
+			continue
 		}
+		count += s.countSolutions(neighbor, cost)
 	}
-
-	return minNextThreshold, nil
+	return count
 }
 
 func isCycle(node *node) bool {
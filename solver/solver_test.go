@@ -1,6 +1,7 @@
 package solver
 
 import (
+	"context"
 	"testing"
 )
 
@@ -91,3 +92,44 @@ func TestIsSolvable(t *testing.T) {
 		})
 	}
 }
+
+func TestSolveContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	board := []int{16, 12, 9, 13, 15, 11, 10, 14, 3, 7, 2, 5, 4, 8, 6, 1}
+	if _, err := SolveContext(ctx, board, StandardGoal(4, 4), 4, 4); err != context.Canceled {
+		t.Errorf("SolveContext() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestSolveWithOptions_Stats(t *testing.T) {
+	var stats Stats
+	path, err := SolveWithOptions(context.Background(), []int{1, 8, 2, 4, 3, 5, 7, 6, 9}, StandardGoal(3, 3), 3, 3, SolveOptions{Stats: &stats})
+	if err != nil {
+		t.Fatalf("SolveWithOptions() error = %v", err)
+	}
+	if len(path)-1 != 10 {
+		t.Errorf("SolveWithOptions() moves = %d, want 10", len(path)-1)
+	}
+	if stats.NodesExpanded == 0 || stats.Iterations == 0 {
+		t.Errorf("SolveWithOptions() stats = %+v, want non-zero counts", stats)
+	}
+}
+
+func TestSolveWithOptions_Progress(t *testing.T) {
+	var reports []Progress
+	opts := SolveOptions{Progress: func(p Progress) { reports = append(reports, p) }}
+	if _, err := SolveWithOptions(context.Background(), []int{1, 8, 2, 4, 3, 5, 7, 6, 9}, StandardGoal(3, 3), 3, 3, opts); err != nil {
+		t.Fatalf("SolveWithOptions() error = %v", err)
+	}
+	// The heuristic is 8 and the solution 10 moves long: one report per threshold.
+	want := []Progress{{Threshold: 8, Iterations: 1}, {Threshold: 10, NodesExpanded: 6, Iterations: 2}}
+	if len(reports) != len(want) {
+		t.Fatalf("got %d progress reports %+v, want %d", len(reports), reports, len(want))
+	}
+	for i := range want {
+		if reports[i].Threshold != want[i].Threshold || reports[i].Iterations != want[i].Iterations {
+			t.Errorf("report %d = %+v, want %+v", i, reports[i], want[i])
+		}
+	}
+}
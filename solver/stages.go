@@ -0,0 +1,183 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// StageResult reports one stage of a staged solution.
+type StageResult struct {
+	// Goal is the stage's goal as it was solved, including the tiles frozen before it.
+	Goal PartialGoal `json:"goal"`
+	// Moves is the optimal number of moves for the stage with the earlier tiles frozen.
+	Moves int `json:"moves"`
+	// Path holds the blank tile indices of the stage, starting where the previous stage ended.
+	Path []int `json:"path"`
+}
+
+// StagedSolution is the result of SolveStages.
+type StagedSolution struct {
+	// Path is the concatenation of the stage paths, in the form returned by Solve.
+	Path []int `json:"path"`
+	// Moves is the total number of moves, len(Path)-1.
+	Moves  int           `json:"moves"`
+	Stages []StageResult `json:"stages"`
+}
+
+// SolveStages solves a puzzle one partial goal at a time, the way people solve large
+// boards: each stage is solved optimally and then the tiles it placed are frozen, so
+// that later stages may not move them. A stage may repeat the requirements of earlier
+// stages, which makes cumulative goals such as StandardStages valid. Only the last stage
+// may place the blank.
+//
+// Each stage is checked before it is searched, exactly for the cells left free by the
+// stages before it, whatever their shape; ErrUnsolvable is returned for the first stage
+// that cannot be reached. opts.Stats receives the work of all stages together.
+// opts.Locked freezes cells from the start, as for SolvePartial; opts.Wrap and the
+// MultiTile metric return ErrUnsupportedOption.
+//
+// Example:
+//
+//	stages, _ := StandardStages(StandardGoal(4, 4), 4, 4) // top row, left column, the rest
+//	s, err := SolveStages(ctx, start, stages, 4, 4, SolveOptions{})
+//	fmt.Println(s.Moves, s.Stages[0].Moves)
+func SolveStages(ctx context.Context, start []int, stages []PartialGoal, rows, cols int, opts SolveOptions) (StagedSolution, error) {
+	if err := validate(start, rows, cols); err != nil {
+		return StagedSolution{}, err
+	}
+	if err := unsupportedOptions(opts, "staged solving"); err != nil {
+		return StagedSolution{}, err
+	}
+	n := rows * cols
+	locked := make([]bool, n)
+	if opts.Locked != nil {
+		if err := (PartialGoal{}).validateLocked(start, opts.Locked); err != nil {
+			return StagedSolution{}, err
+		}
+		for i, stage := range stages {
+			if err := stage.validateLocked(start, opts.Locked); err != nil {
+				return StagedSolution{}, fmt.Errorf("stage %d: %w", i+1, err)
+			}
+		}
+		copy(locked, opts.Locked)
+	}
+	for i, stage := range stages[:max(len(stages)-1, 0)] {
+		blankGrouped := slices.ContainsFunc(stage.Groups, func(g TileGroup) bool { return slices.Contains(g.Tiles, n) })
+		if slices.Contains(stage.Cells, n) || blankGrouped {
+			return StagedSolution{}, fmt.Errorf("stage %d: %w: only the last stage may place the blank", i+1, ErrInvalidPartialGoal)
+		}
+	}
+
+	board := slices.Clone(start)
+	result := StagedSolution{Path: []int{blankIndex(board)}}
+	var total Stats
+	for i, stage := range stages {
+		goal, err := stage.freeze(board, locked)
+		if err != nil {
+			return StagedSolution{}, fmt.Errorf("stage %d: %w", i+1, err)
+		}
+		p, err := goal.compile(rows, cols)
+		if err != nil {
+			return StagedSolution{}, fmt.Errorf("stage %d: %w", i+1, err)
+		}
+		if !p.solvableOn(board, gridGraph(rows, cols, locked)) {
+			return StagedSolution{}, fmt.Errorf("stage %d: %w", i+1, ErrUnsolvable)
+		}
+
+		s := &searcher{ctx: ctx, progress: opts.Progress, target: p, rows: rows, cols: cols}
+		root := newNode(board, rows, cols)
+		root.locked = locked
+		found, err := s.run(root)
+		total.NodesExpanded += s.expanded
+		total.Iterations += s.iterations
+		if opts.Stats != nil {
+			*opts.Stats = total
+		}
+		if err != nil {
+			return StagedSolution{}, fmt.Errorf("stage %d: %w", i+1, err)
+		}
+
+		path := found.path()
+		result.Stages = append(result.Stages, StageResult{Goal: goal, Moves: found.cost, Path: path})
+		result.Path = append(result.Path, path[1:]...)
+		board = found.board
+		for c := range board {
+			if board[c] != n && (p.fixed[c] != Wildcard || p.cellGroup[c] != 0) {
+				locked[c] = true
+			}
+		}
+	}
+	result.Moves = len(result.Path) - 1
+	return result, nil
+}
+
+// freeze returns the goal with the frozen tiles of board added as required tiles.
+// Requiring a frozen tile in its own cell again is allowed.
+func (g PartialGoal) freeze(board []int, locked []bool) (PartialGoal, error) {
+	cells := make([]int, len(board))
+	if g.Cells != nil {
+		if len(g.Cells) != len(board) {
+			return PartialGoal{}, fmt.Errorf("%w: %d cells for a board of %d", ErrInvalidPartialGoal, len(g.Cells), len(board))
+		}
+		copy(cells, g.Cells)
+	}
+	for c, frozen := range locked {
+		if !frozen {
+			continue
+		}
+		if cells[c] != Wildcard && cells[c] != board[c] {
+			return PartialGoal{}, fmt.Errorf("%w: cell %d is frozen with tile %d", ErrInvalidPartialGoal, c, board[c])
+		}
+		cells[c] = board[c]
+	}
+	return PartialGoal{Cells: cells, Groups: g.Groups}, nil
+}
+
+// StandardStages splits a goal into the stages people commonly use: the outermost row
+// or column away from the goal's blank, repeated on the remaining rectangle until it
+// has at most 9 cells, followed by the complete goal. Rows are taken while the remaining
+// rectangle has at least as many rows as columns. Each stage includes the earlier ones.
+//
+// Example:
+//
+//	StandardStages(StandardGoal(4, 4), 4, 4) // 1 2 3 4; then 1 5 9 13 as well; then the whole goal
+func StandardStages(goal []int, rows, cols int) ([]PartialGoal, error) {
+	if err := validate(goal, rows, cols); err != nil {
+		return nil, err
+	}
+	blankRow, blankCol := blankIndex(goal)/cols, blankIndex(goal)%cols
+	cells := make([]int, len(goal))
+	var stages []PartialGoal
+	top, bottom, left, right := 0, rows-1, 0, cols-1
+	for (bottom-top+1)*(right-left+1) > 9 {
+		if bottom-top >= right-left {
+			r := top
+			if blankRow == top {
+				r, bottom = bottom, bottom-1
+			} else {
+				top++
+			}
+			for c := left; c <= right; c++ {
+				cells[r*cols+c] = goal[r*cols+c]
+			}
+		} else {
+			c := left
+			if blankCol == left {
+				c, right = right, right-1
+			} else {
+				left++
+			}
+			for r := top; r <= bottom; r++ {
+				cells[r*cols+c] = goal[r*cols+c]
+			}
+		}
+		stages = append(stages, PartialGoal{Cells: slices.Clone(cells)})
+	}
+	return append(stages, PartialGoal{Cells: slices.Clone(goal)}), nil
+}
+
+// blankIndex returns the cell of the blank of a valid board.
+func blankIndex(board []int) int {
+	return slices.Index(board, len(board))
+}
@@ -0,0 +1,255 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestSolveStages(t *testing.T) {
+	w := Wildcard
+	tests := []struct {
+		name   string
+		start  []int
+		stages []PartialGoal
+		rows   int
+		cols   int
+	}{
+		{
+			"top row then the rest",
+			[]int{4, 1, 3, 7, 2, 6, 5, 8, 9},
+			[]PartialGoal{{Cells: []int{1, 2, 3, w, w, w, w, w, w}}, {Cells: StandardGoal(3, 3)}},
+			3, 3,
+		},
+		{
+			"already solved stage",
+			[]int{1, 2, 3, 9, 8, 7, 6, 5, 4},
+			[]PartialGoal{{Cells: []int{1, 2, 3, w, w, w, w, w, w}}, {Cells: StandardGoal(3, 3)}},
+			3, 3,
+		},
+		{
+			"incremental stages",
+			[]int{2, 6, 8, 1, 4, 9, 3, 7, 5},
+			[]PartialGoal{{Groups: []TileGroup{{Tiles: []int{1, 4, 7}, Cells: []int{0, 3, 6}}}}, {Cells: []int{w, 2, 3, w, 5, w, w, w, w}}},
+			3, 3,
+		},
+		{
+			// With 5 frozen in the center, the other tiles can only rotate around it.
+			"around a frozen center",
+			[]int{4, 1, 2, 7, 5, 3, 8, 9, 6},
+			[]PartialGoal{{Cells: []int{w, w, w, w, 5, w, w, w, w}}, {Cells: StandardGoal(3, 3)}},
+			3, 3,
+		},
+		{
+			"no stages",
+			[]int{2, 1, 3, 4},
+			nil,
+			2, 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SolveStages(context.Background(), tt.start, tt.stages, tt.rows, tt.cols, SolveOptions{})
+			if err != nil {
+				t.Fatalf("SolveStages() error = %v", err)
+			}
+			if len(got.Stages) != len(tt.stages) || got.Moves != len(got.Path)-1 {
+				t.Fatalf("SolveStages() = %+v for %d stages", got, len(tt.stages))
+			}
+
+			board := slices.Clone(tt.start)
+			locked := make([]bool, len(board))
+			for i, stage := range got.Stages {
+				if stage.Path[0] != blankIndex(board) || stage.Moves != len(stage.Path)-1 {
+					t.Fatalf("stage %d path %v does not continue from %v", i+1, stage.Path, board)
+				}
+				if want := stageDistance(t, board, stage.Goal, locked, tt.rows, tt.cols); stage.Moves != want {
+					t.Errorf("stage %d takes %d moves, want %d", i+1, stage.Moves, want)
+				}
+				next := playPath(board, stage.Path)
+				for c, frozen := range locked {
+					if frozen && next[c] != board[c] {
+						t.Errorf("stage %d moved frozen tile %d", i+1, board[c])
+					}
+				}
+				if ok, _ := stage.Goal.Matches(next, tt.rows, tt.cols); !ok {
+					t.Errorf("stage %d ends at %v, which does not match %+v", i+1, next, stage.Goal)
+				}
+				board = next
+				p, _ := stage.Goal.compile(tt.rows, tt.cols)
+				for c, v := range board {
+					if v != len(board) && (p.fixed[c] != Wildcard || p.cellGroup[c] != 0) {
+						locked[c] = true
+					}
+				}
+			}
+			if !slices.Equal(playPath(tt.start, got.Path), board) {
+				t.Errorf("Path %v does not join the stage paths", got.Path)
+			}
+		})
+	}
+}
+
+// stageDistance returns the optimal number of moves from start to goal without moving
+// locked tiles, by breadth-first search, or -1 when the goal cannot be reached.
+func stageDistance(t *testing.T, start []int, goal PartialGoal, locked []bool, rows, cols int) int {
+	t.Helper()
+	p, err := goal.compile(rows, cols)
+	if err != nil {
+		t.Fatalf("compile() error = %v", err)
+	}
+	root := newNode(start, rows, cols)
+	root.locked = locked
+	seen := map[string]bool{boardKey(start): true}
+	frontier := []*node{root}
+	for d := 0; len(frontier) > 0; d++ {
+		var next []*node
+		for _, n := range frontier {
+			if p.matches(n.board) {
+				return d
+			}
+			for _, child := range n.children() {
+				if key := boardKey(child.board); !seen[key] {
+					seen[key] = true
+					next = append(next, child)
+				}
+			}
+		}
+		frontier = next
+	}
+	return -1
+}
+
+func TestSolveStages_Standard4x4(t *testing.T) {
+	start := []int{5, 1, 2, 4, 9, 6, 3, 8, 13, 10, 7, 11, 14, 15, 16, 12}
+	goal := StandardGoal(4, 4)
+	stages, err := StandardStages(goal, 4, 4)
+	if err != nil {
+		t.Fatalf("StandardStages() error = %v", err)
+	}
+	var stats Stats
+	got, err := SolveStages(context.Background(), start, stages, 4, 4, SolveOptions{Stats: &stats})
+	if err != nil {
+		t.Fatalf("SolveStages() error = %v", err)
+	}
+	if !slices.Equal(playPath(start, got.Path), goal) {
+		t.Errorf("Path %v does not solve the puzzle", got.Path)
+	}
+	sum := 0
+	for _, s := range got.Stages {
+		sum += s.Moves
+	}
+	if sum != got.Moves || stats.NodesExpanded == 0 {
+		t.Errorf("stage moves add up to %d, total %d, stats %+v", sum, got.Moves, stats)
+	}
+}
+
+func TestSolveStages_Errors(t *testing.T) {
+	w := Wildcard
+	tests := []struct {
+		name    string
+		start   []int
+		stages  []PartialGoal
+		wantErr error
+	}{
+		{"blank before the last stage", []int{1, 2, 3, 4, 5, 6, 7, 8, 9}, []PartialGoal{{Cells: []int{9, w, w, w, w, w, w, w, w}}, {}}, ErrInvalidPartialGoal},
+		{"frozen tile required elsewhere", []int{1, 2, 3, 4, 5, 6, 7, 9, 8}, []PartialGoal{{Cells: []int{1, w, w, w, w, w, w, w, w}}, {Cells: []int{2, w, w, w, w, w, w, w, w}}}, ErrInvalidPartialGoal},
+		{"unsolvable stage", []int{1, 2, 3, 4, 5, 6, 8, 7, 9}, []PartialGoal{{Cells: []int{1, 2, 3, w, w, w, w, w, w}}, {Cells: StandardGoal(3, 3)}}, ErrUnsolvable},
+		// Solvable on the whole board, but not by rotating the tiles around the frozen 5.
+		{"unsolvable around a frozen center", []int{2, 3, 1, 4, 5, 6, 7, 8, 9}, []PartialGoal{{Cells: []int{w, w, w, w, 5, w, w, w, w}}, {Cells: StandardGoal(3, 3)}}, ErrUnsolvable},
+		{"invalid start", []int{1, 2, 3}, nil, ErrSizeMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := SolveStages(context.Background(), tt.start, tt.stages, 3, 3, SolveOptions{}); !errors.Is(err, tt.wantErr) {
+				t.Errorf("SolveStages() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSolveStages_Options(t *testing.T) {
+	w := Wildcard
+	stages := []PartialGoal{{Cells: []int{1, 2, 3, w, w, w, w, w, w}}, {Cells: StandardGoal(3, 3)}}
+	tests := []struct {
+		name    string
+		start   []int
+		stages  []PartialGoal
+		opts    SolveOptions
+		wantErr error
+	}{
+		// Tile 5 is bolted in the center from the start, not only after a stage placed it.
+		{"locked center", []int{4, 1, 2, 7, 5, 3, 8, 9, 6}, stages, SolveOptions{Locked: lockedMask(9, 4)}, nil},
+		{"unsolvable around a locked center", []int{2, 3, 1, 4, 5, 6, 7, 8, 9}, stages, SolveOptions{Locked: lockedMask(9, 4)}, ErrUnsolvable},
+		{"locked blank", []int{1, 2, 3, 4, 9, 6, 7, 8, 5}, stages, SolveOptions{Locked: lockedMask(9, 4)}, ErrLockedBlank},
+		{"locked tile required elsewhere", []int{4, 1, 2, 7, 5, 3, 8, 9, 6}, []PartialGoal{{Cells: []int{w, 5, w, w, w, w, w, w, w}}}, SolveOptions{Locked: lockedMask(9, 4)}, ErrLockedTile},
+		{"short mask", []int{4, 1, 2, 7, 5, 3, 8, 9, 6}, stages, SolveOptions{Locked: lockedMask(4, 0)}, ErrLockedMask},
+		{"wrap", []int{4, 1, 2, 7, 5, 3, 8, 9, 6}, stages, SolveOptions{Wrap: Cylinder}, ErrUnsupportedOption},
+		{"multi-tile metric", []int{4, 1, 2, 7, 5, 3, 8, 9, 6}, stages, SolveOptions{Metric: MultiTile}, ErrUnsupportedOption},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SolveStages(context.Background(), tt.start, tt.stages, 3, 3, tt.opts)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SolveStages() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			board := slices.Clone(tt.start)
+			for i, stage := range got.Stages {
+				next := playPath(board, stage.Path)
+				for c, l := range tt.opts.Locked {
+					if l && next[c] != tt.start[c] {
+						t.Errorf("stage %d moved locked tile %d", i+1, tt.start[c])
+					}
+				}
+				board = next
+			}
+			if !slices.Equal(board, StandardGoal(3, 3)) {
+				t.Errorf("SolveStages() ends at %v", board)
+			}
+		})
+	}
+}
+
+func TestStandardStages(t *testing.T) {
+	w := Wildcard
+	tests := []struct {
+		name string
+		goal []int
+		rows int
+		cols int
+		want []PartialGoal
+	}{
+		{"3x3 in one stage", StandardGoal(3, 3), 3, 3, []PartialGoal{{Cells: StandardGoal(3, 3)}}},
+		{"4x4", StandardGoal(4, 4), 4, 4, []PartialGoal{
+			{Cells: []int{1, 2, 3, 4, w, w, w, w, w, w, w, w, w, w, w, w}},
+			{Cells: []int{1, 2, 3, 4, 5, w, w, w, 9, w, w, w, 13, w, w, w}},
+			{Cells: StandardGoal(4, 4)},
+		}},
+		// The blank is in the top left corner, so the bottom row and the right column go first.
+		{"4x4 blank first", []int{16, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, 4, 4, []PartialGoal{
+			{Cells: []int{w, w, w, w, w, w, w, w, w, w, w, w, 12, 13, 14, 15}},
+			{Cells: []int{w, w, w, 3, w, w, w, 7, w, w, w, 11, 12, 13, 14, 15}},
+			{Cells: []int{16, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}},
+		}},
+		{"2x5", StandardGoal(2, 5), 2, 5, []PartialGoal{
+			{Cells: []int{1, w, w, w, w, 6, w, w, w, w}},
+			{Cells: StandardGoal(2, 5)},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := StandardStages(tt.goal, tt.rows, tt.cols)
+			if err != nil {
+				t.Fatalf("StandardStages() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("StandardStages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
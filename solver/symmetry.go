@@ -0,0 +1,149 @@
+package solver
+
+import "slices"
+
+// Symmetry is a reflection or rotation of the board combined with a relabeling of the
+// tiles that maps the goal onto itself. Applying it to a board gives a board with the
+// same optimal distance to the goal, and paths map along with the boards.
+type Symmetry struct {
+	// Name is "identity", "mirror-horizontal", "mirror-vertical", "rotate-180",
+	// "transpose", "anti-transpose", "rotate-90" or "rotate-270".
+	Name   string
+	cells  []int // cells[i] is the cell that cell i is moved to
+	labels []int // labels[v] is the tile that tile v is relabeled to
+}
+
+// geometry is a reflection or rotation of a rows x cols grid, mapping row r and
+// column c of the original to a cell of the result.
+type geometry struct {
+	name   string
+	square bool // only valid when rows == cols
+	cell   func(r, c, rows, cols int) (int, int)
+}
+
+var geometries = []geometry{
+	{"identity", false, func(r, c, rows, cols int) (int, int) { return r, c }},
+	{"mirror-horizontal", false, func(r, c, rows, cols int) (int, int) { return r, cols - 1 - c }},
+	{"mirror-vertical", false, func(r, c, rows, cols int) (int, int) { return rows - 1 - r, c }},
+	{"rotate-180", false, func(r, c, rows, cols int) (int, int) { return rows - 1 - r, cols - 1 - c }},
+	{"transpose", true, func(r, c, rows, cols int) (int, int) { return c, r }},
+	{"anti-transpose", true, func(r, c, rows, cols int) (int, int) { return cols - 1 - c, rows - 1 - r }},
+	{"rotate-90", true, func(r, c, rows, cols int) (int, int) { return c, rows - 1 - r }},
+	{"rotate-270", true, func(r, c, rows, cols int) (int, int) { return cols - 1 - c, r }},
+}
+
+// Symmetries returns the symmetries of the goal, starting with the identity.
+// A reflection or rotation is a symmetry when it keeps the goal's blank in place:
+// the tiles can then be relabeled so that the goal maps onto itself. A 3x3 goal with
+// the blank in the center has all eight; the standard goal of a square board has the
+// identity and the transpose.
+//
+// Example:
+//
+//	syms, err := Symmetries(StandardGoal(4, 4), 4, 4)
+//	fmt.Println(len(syms)) // 2
+func Symmetries(goal []int, rows, cols int) ([]Symmetry, error) {
+	if err := validate(goal, rows, cols); err != nil {
+		return nil, err
+	}
+	return goalSymmetries(goal, rows, cols), nil
+}
+
+// goalSymmetries is Symmetries for a valid goal.
+func goalSymmetries(goal []int, rows, cols int) []Symmetry {
+	blank := len(goal)
+	goalPositions := make([]int, len(goal)+1)
+	for i, v := range goal {
+		goalPositions[v] = i
+	}
+
+	var syms []Symmetry
+	for _, g := range geometries {
+		if g.square && rows != cols {
+			continue
+		}
+		cells := make([]int, len(goal))
+		for i := range cells {
+			r, c := g.cell(i/cols, i%cols, rows, cols)
+			cells[i] = r*cols + c
+		}
+		if cells[goalPositions[blank]] != goalPositions[blank] {
+			continue
+		}
+		labels := make([]int, len(goal)+1)
+		for v := 1; v <= blank; v++ {
+			labels[v] = goal[cells[goalPositions[v]]]
+		}
+		syms = append(syms, Symmetry{Name: g.name, cells: cells, labels: labels})
+	}
+	return syms
+}
+
+// Apply returns the image of board under the symmetry.
+func (s Symmetry) Apply(board []int) []int {
+	image := make([]int, len(board))
+	for i, v := range board {
+		image[s.cells[i]] = s.labels[v]
+	}
+	return image
+}
+
+// MapPath returns the image of a path of blank indices under the symmetry.
+// If path solves board, the result solves s.Apply(board).
+func (s Symmetry) MapPath(path []int) []int {
+	image := make([]int, len(path))
+	for i, cell := range path {
+		image[i] = s.cells[cell]
+	}
+	return image
+}
+
+// Inverse returns the symmetry that undoes s.
+func (s Symmetry) Inverse() Symmetry {
+	inv := Symmetry{Name: s.Name, cells: make([]int, len(s.cells)), labels: make([]int, len(s.labels))}
+	for i, cell := range s.cells {
+		inv.cells[cell] = i
+	}
+	for v, label := range s.labels {
+		inv.labels[label] = v
+	}
+	switch s.Name {
+	case "rotate-90":
+		inv.Name = "rotate-270"
+	case "rotate-270":
+		inv.Name = "rotate-90"
+	}
+	return inv
+}
+
+// Canonicalize maps start to the representative of its class of symmetric boards,
+// the lexicographically smallest image under the goal's symmetries. Boards that are
+// symmetric to each other have the same representative. It returns the representative
+// and the symmetry that maps start onto it.
+//
+// Example:
+//
+//	canonical, sym, err := Canonicalize(start, goal, 3, 3)
+//	path, err := Solve(canonical, goal, 3, 3)
+//	path = sym.Inverse().MapPath(path) // solves start
+func Canonicalize(start, goal []int, rows, cols int) ([]int, Symmetry, error) {
+	if err := validate(start, rows, cols); err != nil {
+		return nil, Symmetry{}, err
+	}
+	if err := validate(goal, rows, cols); err != nil {
+		return nil, Symmetry{}, err
+	}
+	canonical, sym := canonicalize(start, goalSymmetries(goal, rows, cols))
+	return canonical, sym, nil
+}
+
+// canonicalize returns the smallest image of board under syms and the symmetry giving it.
+func canonicalize(board []int, syms []Symmetry) ([]int, Symmetry) {
+	best, bestSym := syms[0].Apply(board), syms[0]
+	for _, s := range syms[1:] {
+		if image := s.Apply(board); slices.Compare(image, best) < 0 {
+			best, bestSym = image, s
+		}
+	}
+	return best, bestSym
+}
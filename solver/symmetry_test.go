@@ -0,0 +1,110 @@
+package solver
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestSymmetries(t *testing.T) {
+	tests := []struct {
+		name string
+		goal []int
+		rows int
+		cols int
+		want []string
+	}{
+		{"standard square", StandardGoal(3, 3), 3, 3, []string{"identity", "transpose"}},
+		{"standard rectangle", StandardGoal(2, 3), 2, 3, []string{"identity"}},
+		{"blank in the center", []int{1, 2, 3, 4, 9, 5, 6, 7, 8}, 3, 3,
+			[]string{"identity", "mirror-horizontal", "mirror-vertical", "rotate-180", "transpose", "anti-transpose", "rotate-90", "rotate-270"}},
+		{"blank on the middle column", []int{1, 2, 3, 4, 5, 6, 7, 9, 8}, 3, 3, []string{"identity", "mirror-horizontal"}},
+		{"blank in the top-right corner", []int{1, 2, 9, 3, 4, 5, 6, 7, 8}, 3, 3, []string{"identity", "anti-transpose"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			syms, err := Symmetries(tt.goal, tt.rows, tt.cols)
+			if err != nil {
+				t.Fatalf("Symmetries() error = %v", err)
+			}
+			var names []string
+			for _, s := range syms {
+				names = append(names, s.Name)
+				if got := s.Apply(tt.goal); !slices.Equal(got, tt.goal) {
+					t.Errorf("%s maps the goal to %v", s.Name, got)
+				}
+			}
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("Symmetries() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestSymmetry_Apply(t *testing.T) {
+	syms, _ := Symmetries(StandardGoal(3, 3), 3, 3)
+	// The blank moved left twice from the goal becomes the blank moved up twice.
+	// 1 2 3      1 2 9
+	// 4 5 6  ->  4 5 3
+	// 9 7 8      7 8 6
+	board := []int{1, 2, 3, 4, 5, 6, 9, 7, 8}
+	want := []int{1, 2, 9, 4, 5, 3, 7, 8, 6}
+	if got := syms[1].Apply(board); !reflect.DeepEqual(got, want) {
+		t.Errorf("transpose.Apply() = %v, want %v", got, want)
+	}
+}
+
+func TestSymmetry_PreservesSolutions(t *testing.T) {
+	goal := []int{1, 2, 3, 4, 9, 5, 6, 7, 8}
+	board := []int{4, 1, 3, 6, 2, 5, 7, 9, 8}
+	path, err := Solve(board, goal, 3, 3)
+	if err != nil {
+		t.Fatalf("Solve() error = %v", err)
+	}
+	syms, _ := Symmetries(goal, 3, 3)
+	for _, s := range syms {
+		image := s.Apply(board)
+		if got := playPath(image, s.MapPath(path)); !slices.Equal(got, goal) {
+			t.Errorf("%s: mapped path leads to %v, want the goal", s.Name, got)
+		}
+		if back := s.Inverse().Apply(image); !slices.Equal(back, board) {
+			t.Errorf("%s: Inverse().Apply() = %v, want %v", s.Name, back, board)
+		}
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	goal := []int{1, 2, 3, 4, 9, 5, 6, 7, 8}
+	board := []int{4, 1, 3, 6, 2, 5, 7, 9, 8}
+	canonical, sym, err := Canonicalize(board, goal, 3, 3)
+	if err != nil {
+		t.Fatalf("Canonicalize() error = %v", err)
+	}
+	syms, _ := Symmetries(goal, 3, 3)
+	for _, s := range syms {
+		if got, _, _ := Canonicalize(s.Apply(board), goal, 3, 3); !slices.Equal(got, canonical) {
+			t.Errorf("%s image canonicalizes to %v, want %v", s.Name, got, canonical)
+		}
+	}
+
+	path, err := Solve(canonical, goal, 3, 3)
+	if err != nil {
+		t.Fatalf("Solve() error = %v", err)
+	}
+	if got := playPath(board, sym.Inverse().MapPath(path)); !slices.Equal(got, goal) {
+		t.Errorf("path mapped back leads to %v, want the goal", got)
+	}
+
+	if _, _, err := Canonicalize([]int{1, 2, 3}, goal, 3, 3); err == nil {
+		t.Error("Canonicalize() should reject an invalid board")
+	}
+}
+
+// playPath moves the blank of board along path, which includes the initial position.
+func playPath(board, path []int) []int {
+	board = slices.Clone(board)
+	for i := 1; i < len(path); i++ {
+		board[path[i-1]], board[path[i]] = board[path[i]], board[path[i-1]]
+	}
+	return board
+}
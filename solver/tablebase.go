@@ -0,0 +1,347 @@
+package solver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+var (
+	ErrTablebaseTooLarge = errors.New("board too large for a tablebase")
+	ErrInvalidTablebase  = errors.New("invalid tablebase file")
+)
+
+// MaxTablebaseCells is the largest board, in cells, for which a tablebase can be built.
+// A table holds one byte per solvable board: 1.8 MB for 10 cells.
+const MaxTablebaseCells = 10
+
+// tablebaseMagic starts every tablebase file.
+const tablebaseMagic = "SPTB"
+
+// tablebaseVersion is the version of the file format written by WriteTo.
+const tablebaseVersion = 2
+
+// unreachable marks the boards of a tablebase that cannot reach the goal.
+const unreachable = 0xff
+
+// Tablebase holds the exact distance to a goal of every board of a small puzzle,
+// such as the 8-puzzle or a 2xN puzzle. Distances are stored by the RankSolvable rank
+// of the board, so lookups take time near-linear in the board size.
+// A Tablebase is immutable and safe for concurrent use.
+type Tablebase struct {
+	rows     int
+	cols     int
+	goal     []int
+	ranker   *solvableRanker
+	distance []byte // by rank of the board
+}
+
+// BuildTablebase runs a retrograde breadth-first search from goal and records the
+// distance of every board that can reach it.
+//
+// Example:
+//
+//	tb, err := BuildTablebase(StandardGoal(3, 3), 3, 3)
+//	d, _ := tb.Distance([]int{1, 8, 2, 4, 3, 5, 7, 6, 9}) // 10
+func BuildTablebase(goal []int, rows, cols int) (*Tablebase, error) {
+	return BuildTablebaseContext(context.Background(), goal, rows, cols)
+}
+
+// BuildTablebaseContext is like BuildTablebase but stops with ctx.Err() when ctx is done.
+func BuildTablebaseContext(ctx context.Context, goal []int, rows, cols int) (*Tablebase, error) {
+	if err := validate(goal, rows, cols); err != nil {
+		return nil, err
+	}
+	if rows*cols > MaxTablebaseCells {
+		return nil, ErrTablebaseTooLarge
+	}
+
+	t := newTablebase(slices.Clone(goal), rows, cols)
+	t.distance = bytes.Repeat([]byte{unreachable}, solvableStates(rows*cols))
+	t.distance[t.ranker.rank(goal)] = 0
+
+	board := make([]int, len(goal))
+	frontier := []int{t.ranker.rank(goal)}
+	for depth := 1; len(frontier) > 0; depth++ {
+		var next []int
+		for i, rank := range frontier {
+			if i%1024 == 0 && ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			t.ranker.unrank(rank, board)
+			n := newNode(board, rows, cols)
+			for _, dir := range n.directions() {
+				child := n.copy()
+				child.moveBlank(dir)
+				r := t.ranker.rank(child.board)
+				if t.distance[r] != unreachable {
+					continue
+				}
+				t.distance[r] = byte(depth)
+				next = append(next, r)
+			}
+		}
+		frontier = next
+	}
+	return t, nil
+}
+
+// newTablebase returns a table for goal without distances.
+func newTablebase(goal []int, rows, cols int) *Tablebase {
+	return &Tablebase{rows: rows, cols: cols, goal: goal, ranker: newSolvableRanker(goal, rows, cols)}
+}
+
+// Rows returns the number of rows of the table's boards.
+func (t *Tablebase) Rows() int { return t.rows }
+
+// Cols returns the number of columns of the table's boards.
+func (t *Tablebase) Cols() int { return t.cols }
+
+// Goal returns a copy of the table's goal.
+func (t *Tablebase) Goal() []int { return slices.Clone(t.goal) }
+
+// States returns the number of boards that can reach the goal.
+func (t *Tablebase) States() int {
+	return len(t.distance) - bytes.Count(t.distance, []byte{unreachable})
+}
+
+// MaxDistance returns the largest distance in the table.
+func (t *Tablebase) MaxDistance() int {
+	d := 0
+	for _, v := range t.distance {
+		if v != unreachable {
+			d = max(d, int(v))
+		}
+	}
+	return d
+}
+
+// Distance returns the optimal number of moves from board to the goal.
+// It returns false when the board is invalid for the table or cannot reach the goal.
+func (t *Tablebase) Distance(board []int) (int, bool) {
+	if validate(board, t.rows, t.cols) != nil || !isSolvable(board, t.goal, t.rows, t.cols) {
+		return 0, false
+	}
+	return t.lookup(board)
+}
+
+// lookup is Distance for a valid board that is solvable by the parity argument.
+func (t *Tablebase) lookup(board []int) (int, bool) {
+	d := t.distance[t.ranker.rank(board)]
+	return int(d), d != unreachable
+}
+
+// OptimalMoves returns the blank tile indices reachable from board in one move that
+// keep it on a shortest path. It returns nil when the board is solved, invalid for
+// the table or cannot reach the goal.
+func (t *Tablebase) OptimalMoves(board []int) []int {
+	d, ok := t.Distance(board)
+	if !ok {
+		return nil
+	}
+	return t.hint(board, d).Moves
+}
+
+// hint returns the optimal moves from a board at distance d.
+func (t *Tablebase) hint(board []int, d int) HintResult {
+	n := newNode(board, t.rows, t.cols)
+	result := HintResult{Distance: d}
+	for _, dir := range n.directions() {
+		child := n.copy()
+		child.moveBlank(dir)
+		if cd, _ := t.lookup(child.board); cd == d-1 {
+			result.Moves = append(result.Moves, child.blankIdx)
+		}
+	}
+	return result
+}
+
+// solve follows optimal moves from board to the goal, returning the path of blank
+// indices including the initial position. The board must be valid and solvable by the
+// parity argument; a table without a move one step closer returns ErrInvalidTablebase.
+func (t *Tablebase) solve(board []int) ([]int, error) {
+	d, ok := t.lookup(board)
+	if !ok {
+		return nil, ErrUnsolvable
+	}
+	n := newNode(board, t.rows, t.cols)
+	path := []int{n.blankIdx}
+	for ; d > 0; d-- {
+		next := t.closer(n, d)
+		if next == nil {
+			return nil, fmt.Errorf("%w: no move leads closer to the goal from distance %d", ErrInvalidTablebase, d)
+		}
+		n = next
+		path = append(path, n.blankIdx)
+	}
+	return path, nil
+}
+
+// closer returns a neighbor of n at distance d-1, where d is the distance of n, or nil.
+func (t *Tablebase) closer(n *node, d int) *node {
+	for _, dir := range n.directions() {
+		child := n.copy()
+		child.moveBlank(dir)
+		if cd, ok := t.lookup(child.board); ok && cd == d-1 {
+			return &child
+		}
+	}
+	return nil
+}
+
+// check verifies the distances of a table read from a file: every board reaches the
+// goal, only the goal is at distance 0, and every other board has a neighbor one move
+// closer and none more than one move closer. Distances that pass are exact.
+func (t *Tablebase) check() error {
+	board := make([]int, len(t.goal))
+	goal := t.ranker.rank(t.goal)
+	for r, d := range t.distance {
+		switch {
+		case d == unreachable:
+			return fmt.Errorf("%w: the board of rank %d has no distance", ErrInvalidTablebase, r)
+		case r == goal:
+			if d != 0 {
+				return fmt.Errorf("%w: goal distance is not 0", ErrInvalidTablebase)
+			}
+			continue
+		case d == 0:
+			return fmt.Errorf("%w: the board of rank %d is at distance 0 but is not the goal", ErrInvalidTablebase, r)
+		}
+		t.ranker.unrank(r, board)
+		n := newNode(board, t.rows, t.cols)
+		found := false
+		for _, dir := range n.directions() {
+			child := n.copy()
+			child.moveBlank(dir)
+			cd := t.distance[t.ranker.rank(child.board)]
+			if cd < d-1 {
+				return fmt.Errorf("%w: the board of rank %d is at distance %d next to one at %d", ErrInvalidTablebase, r, d, cd)
+			}
+			found = found || cd == d-1
+		}
+		if !found {
+			return fmt.Errorf("%w: the board of rank %d at distance %d has no neighbor one move closer", ErrInvalidTablebase, r, d)
+		}
+	}
+	return nil
+}
+
+// WriteTo writes the table in a compact binary format: the magic "SPTB", a version
+// byte, the rows, the columns and the goal as one byte each, followed by one distance
+// byte per solvable board in rank order (0xff for unreachable boards).
+func (t *Tablebase) WriteTo(w io.Writer) (int64, error) {
+	header := []byte(tablebaseMagic)
+	header = append(header, tablebaseVersion, byte(t.rows), byte(t.cols))
+	for _, v := range t.goal {
+		header = append(header, byte(v))
+	}
+	n, err := w.Write(header)
+	if err != nil {
+		return int64(n), err
+	}
+	m, err := w.Write(t.distance)
+	return int64(n + m), err
+}
+
+// ReadTablebase reads a table written by WriteTo. Every distance is checked against
+// those of the neighboring boards, so a corrupt body returns ErrInvalidTablebase.
+func ReadTablebase(r io.Reader) (*Tablebase, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(tablebaseMagic)+3)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTablebase, err)
+	}
+	if string(header[:len(tablebaseMagic)]) != tablebaseMagic {
+		return nil, fmt.Errorf("%w: bad magic", ErrInvalidTablebase)
+	}
+	version, rows, cols := header[4], int(header[5]), int(header[6])
+	if version != tablebaseVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidTablebase, version)
+	}
+	if rows*cols > MaxTablebaseCells {
+		return nil, fmt.Errorf("%w: %dx%d is too large", ErrInvalidTablebase, rows, cols)
+	}
+
+	raw := make([]byte, rows*cols)
+	if _, err := io.ReadFull(br, raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTablebase, err)
+	}
+	goal := make([]int, rows*cols)
+	for i, v := range raw {
+		goal[i] = int(v)
+	}
+	if err := validate(goal, rows, cols); err != nil {
+		return nil, fmt.Errorf("%w: goal: %v", ErrInvalidTablebase, err)
+	}
+
+	t := newTablebase(goal, rows, cols)
+	t.distance = make([]byte, solvableStates(rows*cols))
+	if _, err := io.ReadFull(br, t.distance); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTablebase, err)
+	}
+	if _, err := br.ReadByte(); err != io.EOF {
+		return nil, fmt.Errorf("%w: trailing data", ErrInvalidTablebase)
+	}
+	if err := t.check(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// loadedTablebases are the tables used automatically by Solve and Hint,
+// keyed by tablebaseKey.
+var loadedTablebases = struct {
+	sync.RWMutex
+	tables map[string]*Tablebase
+}{tables: make(map[string]*Tablebase)}
+
+// LoadTablebase makes Solve, SolveWithOptions and Hint answer puzzles with the
+// table's shape and goal from the table instead of searching. It replaces any table
+// loaded before for the same shape and goal.
+//
+// Example:
+//
+//	f, _ := os.Open("3x3.tb")
+//	tb, err := ReadTablebase(f)
+//	if err == nil {
+//		LoadTablebase(tb)
+//	}
+func LoadTablebase(t *Tablebase) {
+	loadedTablebases.Lock()
+	defer loadedTablebases.Unlock()
+	loadedTablebases.tables[tablebaseKey(t.goal, t.rows, t.cols)] = t
+}
+
+// UnloadTablebases forgets every table passed to LoadTablebase.
+func UnloadTablebases() {
+	loadedTablebases.Lock()
+	defer loadedTablebases.Unlock()
+	clear(loadedTablebases.tables)
+}
+
+// loadedTablebase returns the table loaded for the shape and goal, or nil.
+func loadedTablebase(goal []int, rows, cols int) *Tablebase {
+	loadedTablebases.RLock()
+	defer loadedTablebases.RUnlock()
+	return loadedTablebases.tables[tablebaseKey(goal, rows, cols)]
+}
+
+// tablebaseKey identifies the tables for a shape and goal.
+func tablebaseKey(goal []int, rows, cols int) string {
+	return strconv.Itoa(rows) + "x" + strconv.Itoa(cols) + ":" + boardKey(goal)
+}
+
+// factorial returns n!.
+func factorial(n int) int {
+	f := 1
+	for i := 2; i <= n; i++ {
+		f *= i
+	}
+	return f
+}
@@ -0,0 +1,173 @@
+package solver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestBuildTablebase(t *testing.T) {
+	tests := []struct {
+		name        string
+		rows        int
+		cols        int
+		wantStates  int
+		wantMaxDist int
+	}{
+		{"2x2", 2, 2, 12, 6},
+		{"2x3", 2, 3, 360, 21},
+		{"2x4", 2, 4, 20160, 36},
+		{"3x3", 3, 3, 181440, 31},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb, err := BuildTablebase(StandardGoal(tt.rows, tt.cols), tt.rows, tt.cols)
+			if err != nil {
+				t.Fatalf("BuildTablebase() error = %v", err)
+			}
+			if got := tb.States(); got != tt.wantStates {
+				t.Errorf("States() = %d, want %d", got, tt.wantStates)
+			}
+			if got := tb.MaxDistance(); got != tt.wantMaxDist {
+				t.Errorf("MaxDistance() = %d, want %d", got, tt.wantMaxDist)
+			}
+		})
+	}
+
+	if _, err := BuildTablebase(StandardGoal(3, 4), 3, 4); err != ErrTablebaseTooLarge {
+		t.Errorf("BuildTablebase(3x4) error = %v, want %v", err, ErrTablebaseTooLarge)
+	}
+}
+
+func TestTablebase_Lookup(t *testing.T) {
+	goal := StandardGoal(3, 3)
+	tb, err := BuildTablebase(goal, 3, 3)
+	if err != nil {
+		t.Fatalf("BuildTablebase() error = %v", err)
+	}
+
+	for _, board := range [][]int{
+		{1, 8, 2, 4, 3, 5, 7, 6, 9},
+		{8, 6, 7, 2, 5, 4, 3, 9, 1},
+		{1, 2, 3, 4, 9, 8, 7, 6, 5},
+	} {
+		path, err := Solve(board, goal, 3, 3)
+		if err != nil {
+			t.Fatalf("Solve(%v) error = %v", board, err)
+		}
+		if d, ok := tb.Distance(board); !ok || d != len(path)-1 {
+			t.Errorf("Distance(%v) = %d, %v, want %d", board, d, ok, len(path)-1)
+		}
+	}
+
+	// Two optimal solutions diverge at the first move.
+	moves := tb.OptimalMoves([]int{1, 2, 3, 4, 9, 8, 7, 6, 5})
+	slices.Sort(moves)
+	if !slices.Equal(moves, []int{5, 7}) {
+		t.Errorf("OptimalMoves() = %v, want [5 7]", moves)
+	}
+	if _, ok := tb.Distance([]int{2, 1, 3, 4, 5, 6, 7, 8, 9}); ok {
+		t.Error("Distance() of an unsolvable board should fail")
+	}
+	if _, ok := tb.Distance([]int{1, 2, 3}); ok {
+		t.Error("Distance() of a board of the wrong size should fail")
+	}
+}
+
+func TestTablebase_ReadWrite(t *testing.T) {
+	goal := []int{1, 2, 3, 4, 6, 5}
+	tb, err := BuildTablebase(StandardGoal(2, 3), 2, 3)
+	if err != nil {
+		t.Fatalf("BuildTablebase() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := tb.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if want := 4 + 3 + 6 + 360; buf.Len() != want {
+		t.Errorf("file size = %d, want %d", buf.Len(), want)
+	}
+	data := buf.Bytes()
+
+	read, err := ReadTablebase(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadTablebase() error = %v", err)
+	}
+	if read.Rows() != 2 || read.Cols() != 3 || !slices.Equal(read.Goal(), StandardGoal(2, 3)) || !bytes.Equal(read.distance, tb.distance) {
+		t.Error("ReadTablebase() does not match the table written")
+	}
+	if d, _ := read.Distance(goal); d != 1 {
+		t.Errorf("Distance() = %d, want 1", d)
+	}
+
+	// withDistance returns data with the distance of one board replaced.
+	body := len(data) - len(tb.distance)
+	withDistance := func(board []int, d byte) []byte {
+		data := slices.Clone(data)
+		data[body+tb.ranker.rank(board)] = d
+		return data
+	}
+	corrupt := map[string][]byte{
+		"empty":         nil,
+		"magic":         append([]byte("XXXX"), data[4:]...),
+		"version":       append(append([]byte("SPTB"), 9), data[5:]...),
+		"truncated":     data[:len(data)-1],
+		"trailing":      append(slices.Clone(data), 0),
+		"goal not at 0": withDistance(StandardGoal(2, 3), 1),
+		"second 0":      withDistance(goal, 0),
+		"unreachable":   withDistance(goal, unreachable),
+		"too far":       withDistance(goal, 3),
+		"zeroed body":   append(slices.Clone(data[:body]), make([]byte, len(tb.distance))...),
+	}
+	for name, data := range corrupt {
+		if _, err := ReadTablebase(bytes.NewReader(data)); !errors.Is(err, ErrInvalidTablebase) {
+			t.Errorf("%s: ReadTablebase() error = %v, want %v", name, err, ErrInvalidTablebase)
+		}
+	}
+}
+
+func TestLoadTablebase(t *testing.T) {
+	goal := StandardGoal(2, 3)
+	tb, err := BuildTablebase(goal, 2, 3)
+	if err != nil {
+		t.Fatalf("BuildTablebase() error = %v", err)
+	}
+	LoadTablebase(tb)
+	defer UnloadTablebases()
+
+	board := []int{6, 5, 4, 3, 2, 1}
+	var stats Stats
+	path, err := SolveWithOptions(context.Background(), board, goal, 2, 3, SolveOptions{Stats: &stats})
+	if err != nil {
+		t.Fatalf("SolveWithOptions() error = %v", err)
+	}
+	if !stats.TablebaseHit {
+		t.Error("SolveWithOptions() should use the loaded tablebase")
+	}
+	if d, _ := tb.Distance(board); len(path)-1 != d {
+		t.Errorf("path has %d moves, want %d", len(path)-1, d)
+	}
+	if got := playPath(board, path); !slices.Equal(got, goal) {
+		t.Errorf("path leads to %v, want the goal", got)
+	}
+
+	if _, err := Solve([]int{2, 1, 3, 4, 5, 6}, goal, 2, 3); err != ErrUnsolvable {
+		t.Errorf("Solve() error = %v, want %v", err, ErrUnsolvable)
+	}
+}
+
+func TestTablebase_SolveCorrupt(t *testing.T) {
+	goal := StandardGoal(2, 3)
+	tb, err := BuildTablebase(goal, 2, 3)
+	if err != nil {
+		t.Fatalf("BuildTablebase() error = %v", err)
+	}
+	// The board one move from the goal claims to be two moves away.
+	board := []int{1, 2, 3, 4, 6, 5}
+	tb.distance[tb.ranker.rank(board)] = 2
+	if _, err := tb.solve(board); !errors.Is(err, ErrInvalidTablebase) {
+		t.Errorf("solve() error = %v, want %v", err, ErrInvalidTablebase)
+	}
+}
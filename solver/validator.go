@@ -2,8 +2,9 @@ package solver
 
 import (
 	"errors"
+	"fmt"
 	"math"
-	"slices"
+	"strings"
 )
 
 var (
@@ -11,8 +12,65 @@ var (
 	ErrInvalidSize    = errors.New("invalid puzzle size")
 	ErrSizeMismatch   = errors.New("board length does not match rows*cols")
 	ErrInvalidElement = errors.New("board must contain numbers from 1 to len(board)")
+	ErrLockedMask     = errors.New("locked mask length does not match the board")
+	ErrLockedTile     = errors.New("locked cells differ between start and goal")
+	ErrLockedBlank    = errors.New("the blank cannot be in a locked cell")
 )
 
+// ValidationError describes which cells of a board failed validation.
+// It wraps ErrSizeMismatch or ErrInvalidElement, so errors.Is keeps working.
+type ValidationError struct {
+	Err error `json:"-"`
+	// ExpectedLength and ActualLength are the required and the given board lengths.
+	ExpectedLength int `json:"expected_length"`
+	ActualLength   int `json:"actual_length"`
+	// Missing lists the values from 1 to ExpectedLength that do not appear on the board.
+	Missing []int `json:"missing,omitempty"`
+	// Duplicates lists the values that appear more than once.
+	Duplicates []Duplicate `json:"duplicates,omitempty"`
+	// OutOfRange lists the cells holding values outside 1 to ExpectedLength.
+	OutOfRange []Cell `json:"out_of_range,omitempty"`
+}
+
+// Duplicate is a value found at several positions of a board.
+type Duplicate struct {
+	Value     int   `json:"value"`
+	Positions []int `json:"positions"`
+}
+
+// Cell is a value at a position of a board.
+type Cell struct {
+	Index int `json:"index"`
+	Value int `json:"value"`
+}
+
+func (e *ValidationError) Error() string {
+	var details []string
+	if e.ActualLength != e.ExpectedLength {
+		details = append(details, fmt.Sprintf("expected %d values, got %d", e.ExpectedLength, e.ActualLength))
+	}
+	if len(e.Missing) > 0 {
+		details = append(details, fmt.Sprintf("missing %v", e.Missing))
+	}
+	for _, d := range e.Duplicates {
+		details = append(details, fmt.Sprintf("%d repeated at positions %v", d.Value, d.Positions))
+	}
+	for _, c := range e.OutOfRange {
+		details = append(details, fmt.Sprintf("%d out of range at position %d", c.Value, c.Index))
+	}
+	return e.Err.Error() + ": " + strings.Join(details, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks that board is a valid configuration of a rows x cols puzzle.
+// See validate for the rules; size and element errors are returned as *ValidationError.
+func Validate(board []int, rows, cols int) error {
+	return validate(board, rows, cols)
+}
+
 // validate checks if the board slice represents a valid puzzle configuration.
 // It verifies that the board is not empty, the grid size is within limits,
 // the board length matches the specified rows and columns, and the board contains
@@ -21,25 +79,90 @@ var (
 // Example:
 //
 //	validate([]int{1, 2, 3, 4}, 2, 2) // returns nil
-//	validate([]int{1, 2, 3, 0}, 2, 2) // returns ErrInvalidElement
+//	validate([]int{1, 2, 3, 0}, 2, 2) // returns a *ValidationError wrapping ErrInvalidElement
 func validate(board []int, rows, cols int) error {
+	if err := validateSize(board, rows, cols); err != nil {
+		return err
+	}
+	return validateElements(board)
+}
+
+// validateSize checks that board is not empty, that the grid size is within limits and
+// that the board length matches the specified rows and columns.
+func validateSize(board []int, rows, cols int) error {
 	if len(board) == 0 {
 		return ErrEmptyBoard
 	}
 
-	if rows < 2 || cols < 2 || rows > math.MaxInt/cols {
-		return ErrInvalidSize
+	if err := validateShape(rows, cols); err != nil {
+		return err
 	}
 
 	if len(board) != rows*cols {
-		return ErrSizeMismatch
+		return &ValidationError{Err: ErrSizeMismatch, ExpectedLength: rows * cols, ActualLength: len(board)}
 	}
+	return nil
+}
 
-	for i := 1; i <= len(board); i++ {
-		if !slices.Contains(board, i) {
-			return ErrInvalidElement
+// validateShape checks that a rows x cols grid has at least two rows and columns and
+// that its number of cells fits in an int.
+func validateShape(rows, cols int) error {
+	if rows < 2 || cols < 2 || rows > math.MaxInt/cols {
+		return ErrInvalidSize
+	}
+	return nil
+}
+
+// validateElements checks that board holds a permutation of the numbers from 1 to
+// len(board), returning a *ValidationError wrapping ErrInvalidElement otherwise.
+func validateElements(board []int) error {
+	positions := make([][]int, len(board)+1)
+	e := &ValidationError{Err: ErrInvalidElement, ExpectedLength: len(board), ActualLength: len(board)}
+	for i, v := range board {
+		if v < 1 || v > len(board) {
+			e.OutOfRange = append(e.OutOfRange, Cell{Index: i, Value: v})
+			continue
 		}
+		positions[v] = append(positions[v], i)
+	}
+	for v := 1; v <= len(board); v++ {
+		switch len(positions[v]) {
+		case 0:
+			e.Missing = append(e.Missing, v)
+		case 1:
+		default:
+			e.Duplicates = append(e.Duplicates, Duplicate{Value: v, Positions: positions[v]})
+		}
+	}
+	if len(e.Missing) > 0 {
+		return e
 	}
+	return nil
+}
 
+// validateLocked checks a mask of locked cells against valid start and goal boards:
+// it must cover every cell, locked cells must hold the same tile in both boards, and
+// neither blank may be locked in.
+//
+// Example:
+//
+//	validateLocked([]int{1, 2, 4, 3}, []int{1, 2, 3, 4}, []bool{true, false, false, false}) // returns nil
+//	validateLocked([]int{2, 1, 3, 4}, []int{1, 2, 3, 4}, []bool{true, false, false, false}) // returns ErrLockedTile
+func validateLocked(start, goal []int, locked []bool) error {
+	if len(locked) != len(start) {
+		return ErrLockedMask
+	}
+	blank := len(start)
+	for i, l := range locked {
+		if !l {
+			continue
+		}
+		if start[i] == blank || goal[i] == blank {
+			return ErrLockedBlank
+		}
+		if start[i] != goal[i] {
+			return ErrLockedTile
+		}
+	}
 	return nil
 }
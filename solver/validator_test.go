@@ -1,6 +1,8 @@
 package solver
 
 import (
+	"errors"
+	"reflect"
 	"testing"
 )
 
@@ -73,9 +75,97 @@ func TestValidate(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			err := validate(tt.board, tt.rows, tt.cols)
-			if err != tt.wantErr {
+			if !errors.Is(err, tt.wantErr) {
 				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func TestValidate_Details(t *testing.T) {
+	tests := []struct {
+		name  string
+		board []int
+		rows  int
+		cols  int
+		want  *ValidationError
+	}{
+		{
+			name:  "size mismatch",
+			board: []int{1, 2, 3},
+			rows:  2,
+			cols:  2,
+			want:  &ValidationError{Err: ErrSizeMismatch, ExpectedLength: 4, ActualLength: 3},
+		},
+		{
+			name:  "out of range",
+			board: []int{0, 1, 2, 3},
+			rows:  2,
+			cols:  2,
+			want: &ValidationError{
+				Err:            ErrInvalidElement,
+				ExpectedLength: 4,
+				ActualLength:   4,
+				Missing:        []int{4},
+				OutOfRange:     []Cell{{Index: 0, Value: 0}},
+			},
+		},
+		{
+			name:  "duplicates",
+			board: []int{1, 1, 3, 3, 5, 6},
+			rows:  2,
+			cols:  3,
+			want: &ValidationError{
+				Err:            ErrInvalidElement,
+				ExpectedLength: 6,
+				ActualLength:   6,
+				Missing:        []int{2, 4},
+				Duplicates:     []Duplicate{{Value: 1, Positions: []int{0, 1}}, {Value: 3, Positions: []int{2, 3}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *ValidationError
+			if !errors.As(validate(tt.board, tt.rows, tt.cols), &got) {
+				t.Fatalf("validate() should return a *ValidationError")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidationError_Error(t *testing.T) {
+	err := validate([]int{1, 1, 5, 4}, 2, 2)
+	want := "board must contain numbers from 1 to len(board): missing [2 3]; 1 repeated at positions [0 1]; 5 out of range at position 2"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestValidateLocked(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   []int
+		goal    []int
+		locked  []bool
+		wantErr error
+	}{
+		{"no locked cells", []int{2, 1, 3, 4}, []int{1, 2, 3, 4}, []bool{false, false, false, false}, nil},
+		{"locked tile in place", []int{1, 2, 4, 3}, []int{1, 2, 3, 4}, []bool{true, false, false, false}, nil},
+		{"short mask", []int{1, 2, 3, 4}, []int{1, 2, 3, 4}, []bool{true}, ErrLockedMask},
+		{"locked tile moved", []int{2, 1, 3, 4}, []int{1, 2, 3, 4}, []bool{true, false, false, false}, ErrLockedTile},
+		{"blank locked in the start", []int{4, 2, 3, 1}, []int{1, 2, 3, 4}, []bool{true, false, false, false}, ErrLockedBlank},
+		{"blank locked in the goal", []int{1, 2, 3, 4}, []int{1, 2, 3, 4}, []bool{false, false, false, true}, ErrLockedBlank},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateLocked(tt.start, tt.goal, tt.locked); !errors.Is(err, tt.wantErr) {
+				t.Errorf("validateLocked() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package solver
+
+// Wrap selects which edges of the board the blank may cross. A move across an edge takes
+// the blank to the opposite edge, in the same row or column.
+type Wrap int
+
+const (
+	// NoWrap is the ordinary board.
+	NoWrap Wrap = iota
+	// Cylinder joins the left and right edges: the blank moves from the first column to
+	// the last and back.
+	Cylinder
+	// Torus joins the top and bottom edges as well.
+	Torus
+)
+
+// String returns the name of the mode: "none", "cylinder" or "torus".
+func (w Wrap) String() string {
+	switch w {
+	case NoWrap:
+		return "none"
+	case Cylinder:
+		return "cylinder"
+	case Torus:
+		return "torus"
+	default:
+		return "unknown"
+	}
+}
+
+// horizontal reports whether the blank may cross the left and right edges of a board with
+// cols columns. Two columns are already neighbors, so they never wrap.
+func (w Wrap) horizontal(cols int) bool {
+	return (w == Cylinder || w == Torus) && cols > 2
+}
+
+// vertical reports whether the blank may cross the top and bottom edges of a board with
+// rows rows.
+func (w Wrap) vertical(rows int) bool {
+	return w == Torus && rows > 2
+}
+
+// WrapSolvable reports whether start can reach goal when moves wrap around the board.
+//
+// Joining the edges of a row or column of odd length creates an odd cycle, which makes
+// every arrangement reachable: the parity rule of CheckSolvability only survives when every
+// wrapping row and column has even length. The decision is exact, by Wilson's theorem on
+// the graph of the board; see GraphSolvable.
+//
+// Example:
+//
+//	// Two swapped tiles cannot be fixed on a 3x3 board, but can on a 3x3 cylinder.
+//	WrapSolvable([]int{2, 1, 3, 4, 5, 6, 7, 8, 9}, StandardGoal(3, 3), 3, 3, Cylinder) // true
+func WrapSolvable(start, goal []int, rows, cols int, wrap Wrap) (bool, error) {
+	if err := validate(start, rows, cols); err != nil {
+		return false, err
+	}
+	if err := validate(goal, rows, cols); err != nil {
+		return false, err
+	}
+	return wrappedGrid(rows, cols, wrap, nil).solvable(start, goal), nil
+}
+
+// wrappedDistance returns the number of steps between positions a and b of a line of n
+// cells, going around when the line wraps.
+//
+// Example:
+//
+//	wrappedDistance(0, 3, 4, true) // returns 1
+func wrappedDistance(a, b, n int, wraps bool) int {
+	d := abs(a - b)
+	if wraps {
+		d = min(d, n-d)
+	}
+	return d
+}
+
+// wrappedHeuristic is calculateHeuristic for boards whose moves wrap around: the Manhattan
+// distance measured around the wrapping rows and columns, plus the linear conflicts of
+// every row and column.
+func wrappedHeuristic(board, goal []int, rows, cols int, wrap Wrap) int {
+	if wrap == NoWrap {
+		return calculateHeuristic(board, goal, rows, cols)
+	}
+	horizontal, vertical := wrap.horizontal(cols), wrap.vertical(rows)
+	blank := len(board)
+	goalPositions := make(map[int]int, len(goal))
+	for i, v := range goal {
+		goalPositions[v] = i
+	}
+
+	h := 0
+	for i, v := range board {
+		if g, ok := goalPositions[v]; ok && v != blank {
+			h += wrappedDistance(i/cols, g/cols, rows, vertical) + wrappedDistance(i%cols, g%cols, cols, horizontal)
+		}
+	}
+
+	// Lines hold the tiles whose goal is in the same row or column, in order.
+	for r := 0; r < rows; r++ {
+		var line, from, to []int
+		for c := 0; c < cols; c++ {
+			v := board[r*cols+c]
+			if g, ok := goalPositions[v]; ok && v != blank && g/cols == r {
+				line, from, to = append(line, v), append(from, c), append(to, g%cols)
+			}
+		}
+		h += 2 * lineConflicts(line, from, to, cols, horizontal, goalPositions)
+	}
+	for c := 0; c < cols; c++ {
+		var line, from, to []int
+		for r := 0; r < rows; r++ {
+			v := board[r*cols+c]
+			if g, ok := goalPositions[v]; ok && v != blank && g%cols == c {
+				line, from, to = append(line, v), append(from, r), append(to, g/cols)
+			}
+		}
+		h += 2 * lineConflicts(line, from, to, rows, vertical, goalPositions)
+	}
+	return h
+}
+
+// lineConflicts returns the number of tiles of a line of n cells that have to step out
+// of their shortest route for the others to get past: line lists the tiles in order,
+// from and to their positions and goal positions along the line.
+//
+// In a line that wraps, tiles still cannot pass each other, but a tile can also go
+// around the other way, which costs n-2d more moves for a tile d steps from its goal.
+// Only tiles for which that costs at least 2 moves count, like stepping out of the line,
+// and tiles already in place only count in lines of 4 or more cells, so that going
+// around never settles a row and a column conflict for less than the 4 moves counted.
+func lineConflicts(line, from, to []int, n int, wraps bool, goalPositions map[int]int) int {
+	if !wraps {
+		return countConflicts(line, goalPositions)
+	}
+	type routed struct{ at, by int } // position and signed shortest displacement
+	var tiles []routed
+	for i := range line {
+		by := to[i] - from[i]
+		if by > n/2 {
+			by -= n
+		} else if by < -n/2 {
+			by += n
+		}
+		if n-2*abs(by) < 2 || (by == 0 && n < 4) {
+			continue
+		}
+		tiles = append(tiles, routed{from[i], by})
+	}
+
+	// Two tiles conflict when their routes, unrolled along the repeating line, cross.
+	conflict := make([][]bool, len(tiles))
+	for i, a := range tiles {
+		conflict[i] = make([]bool, len(tiles))
+		for j, b := range tiles {
+			for k := -1; k <= 1 && i != j; k++ {
+				before := b.at + k*n - a.at
+				after := before + b.by - a.by
+				if (before < 0) != (after < 0) {
+					conflict[i][j] = true
+				}
+			}
+		}
+	}
+	return minCover(conflict, make([]bool, len(tiles)))
+}
+
+// minCover returns the fewest tiles, among those not yet removed, whose removal leaves no
+// two conflicting tiles.
+func minCover(conflict [][]bool, removed []bool) int {
+	for i := range conflict {
+		for j := i + 1; j < len(conflict); j++ {
+			if removed[i] || removed[j] || !conflict[i][j] {
+				continue
+			}
+			// One of the two has to go.
+			best := len(conflict)
+			for _, k := range []int{i, j} {
+				removed[k] = true
+				best = min(best, 1+minCover(conflict, removed))
+				removed[k] = false
+			}
+			return best
+		}
+	}
+	return 0
+}
@@ -0,0 +1,258 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestWrap_String(t *testing.T) {
+	for w, want := range map[Wrap]string{NoWrap: "none", Cylinder: "cylinder", Torus: "torus", Wrap(7): "unknown"} {
+		if got := w.String(); got != want {
+			t.Errorf("Wrap(%d).String() = %q, want %q", int(w), got, want)
+		}
+	}
+}
+
+func TestWrappedGrid(t *testing.T) {
+	tests := []struct {
+		name string
+		rows int
+		cols int
+		wrap Wrap
+		cell int
+		want []int
+	}{
+		{"torus corner", 3, 3, Torus, 0, []int{6, 3, 2, 1}},
+		{"cylinder corner", 3, 3, Cylinder, 0, []int{3, 2, 1}},
+		{"cylinder edge", 3, 4, Cylinder, 7, []int{3, 11, 6, 4}},
+		// Two rows or columns are neighbors already, so they do not wrap.
+		{"torus of 2 rows", 2, 3, Torus, 0, []int{3, 2, 1}},
+		{"cylinder of 2 columns", 3, 2, Cylinder, 0, []int{2, 1}},
+		{"no wrap", 3, 3, NoWrap, 0, []int{3, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrappedGrid(tt.rows, tt.cols, tt.wrap, nil).adj[tt.cell]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("neighbors of %d = %v, want %v", tt.cell, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapSolvable_Exhaustive(t *testing.T) {
+	tests := []struct {
+		name      string
+		rows      int
+		cols      int
+		wrap      Wrap
+		reachable int
+	}{
+		// An odd ring makes every arrangement reachable; even rings keep the parity rule.
+		{"2x3 cylinder", 2, 3, Cylinder, 720},
+		{"2x4 cylinder", 2, 4, Cylinder, 20160},
+		{"4x2 torus", 4, 2, Torus, 20160},
+		{"3x2 torus", 3, 2, Torus, 720},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := wrappedGrid(tt.rows, tt.cols, tt.wrap, nil)
+			goal := StandardGoal(tt.rows, tt.cols)
+			if got := len(reachableBoards(g, goal)); got != tt.reachable {
+				t.Fatalf("search reaches %d boards, want %d", got, tt.reachable)
+			}
+			checkSolvable(t, tt.name, g, goal)
+		})
+	}
+}
+
+func TestWrapSolvable(t *testing.T) {
+	swapped := []int{2, 1, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	tests := []struct {
+		name    string
+		start   []int
+		rows    int
+		cols    int
+		wrap    Wrap
+		want    bool
+		wantErr error
+	}{
+		{"3x3 cylinder swap", []int{2, 1, 3, 4, 5, 6, 7, 8, 9}, 3, 3, Cylinder, true, nil},
+		{"3x3 swap without wrap", []int{2, 1, 3, 4, 5, 6, 7, 8, 9}, 3, 3, NoWrap, false, nil},
+		{"4x4 torus swap", swapped, 4, 4, Torus, false, nil},
+		{"4x3 torus swap", swapped[:12], 4, 3, Torus, true, nil},
+		{"4x4 torus blank across the edge", []int{1, 2, 3, 16, 5, 6, 7, 4, 9, 10, 11, 8, 13, 14, 15, 12}, 4, 4, Torus, true, nil},
+		{"invalid board", []int{1, 2, 3}, 2, 2, Torus, false, ErrSizeMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := WrapSolvable(tt.start, StandardGoal(tt.rows, tt.cols), tt.rows, tt.cols, tt.wrap)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("WrapSolvable() = %v, %v; want %v, %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+// wrapDistances returns the distance to goal of every board reachable on a wrapped grid,
+// indexed by the rank of the board as a permutation, or -1.
+func wrapDistances(goal []int, rows, cols int, wrap Wrap) []int {
+	g := wrappedGrid(rows, cols, wrap, nil)
+	perm := func(board []int) []int {
+		p := make([]int, len(board))
+		for i, v := range board {
+			p[i] = v - 1
+		}
+		return p
+	}
+	dist := make([]int, factorial(len(goal)))
+	for i := range dist {
+		dist[i] = -1
+	}
+	dist[rankPartialPermutation(perm(goal), len(goal))] = 0
+	queue := [][]int{goal}
+	for len(queue) > 0 {
+		b := queue[0]
+		queue = queue[1:]
+		d := dist[rankPartialPermutation(perm(b), len(goal))]
+		blank := slices.Index(b, len(b))
+		for _, w := range g.adj[blank] {
+			next := slices.Clone(b)
+			next[blank], next[w] = next[w], next[blank]
+			if r := rankPartialPermutation(perm(next), len(goal)); dist[r] == -1 {
+				dist[r] = d + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+	return dist
+}
+
+func TestWrappedHeuristic_Admissible(t *testing.T) {
+	tests := []struct {
+		name      string
+		rows      int
+		cols      int
+		wrap      Wrap
+		conflicts bool // whether some board has linear conflicts
+	}{
+		{"2x4 cylinder", 2, 4, Cylinder, true},
+		{"4x2 torus", 4, 2, Torus, true},
+		{"2x4 torus", 2, 4, Torus, true},
+		// Going around a line of 3 costs too little for conflicts to count.
+		{"3x3 torus", 3, 3, Torus, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goal := StandardGoal(tt.rows, tt.cols)
+			dist := wrapDistances(goal, tt.rows, tt.cols, tt.wrap)
+			conflicts := 0
+			for r, d := range dist {
+				if d == -1 {
+					continue
+				}
+				board := unrankPartialPermutation(r, len(goal), len(goal))
+				for i := range board {
+					board[i]++
+				}
+				h := wrappedHeuristic(board, goal, tt.rows, tt.cols, tt.wrap)
+				if h > d {
+					t.Fatalf("heuristic(%v) = %d, but the board is %d moves from the goal", board, h, d)
+				}
+				manhattan := 0
+				for i, v := range board {
+					if v != len(board) {
+						manhattan += wrappedDistance(i/tt.cols, (v-1)/tt.cols, tt.rows, tt.wrap.vertical(tt.rows)) +
+							wrappedDistance(i%tt.cols, (v-1)%tt.cols, tt.cols, tt.wrap.horizontal(tt.cols))
+					}
+				}
+				if h > manhattan {
+					conflicts++
+				}
+			}
+			if (conflicts > 0) != tt.conflicts {
+				t.Errorf("%d boards have linear conflicts", conflicts)
+			}
+		})
+	}
+}
+
+func TestLineConflicts(t *testing.T) {
+	tests := []struct {
+		name  string
+		from  []int
+		to    []int
+		n     int
+		wraps bool
+		want  int
+	}{
+		{"reversed pair", []int{0, 1}, []int{1, 0}, 3, false, 1},
+		// Around a ring of 5, tile 2 can go the other way for 3 more moves.
+		{"reversed pair on a ring", []int{0, 1}, []int{1, 0}, 5, true, 1},
+		// On a ring of 3, the other way costs only 1 more move.
+		{"reversed pair on a short ring", []int{0, 1}, []int{1, 0}, 3, true, 0},
+		// Both tiles move right by one across the joined edges without crossing.
+		{"shift across the edge", []int{0, 3}, []int{1, 0}, 4, true, 0},
+		{"rotation of three", []int{0, 1, 2}, []int{1, 2, 0}, 6, true, 1},
+		{"tile in place passed", []int{0, 1}, []int{2, 1}, 6, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := make([]int, len(tt.from))
+			goalPositions := map[int]int{}
+			for i := range line {
+				line[i] = i + 1
+				goalPositions[i+1] = tt.to[i]
+			}
+			if got := lineConflicts(line, tt.from, tt.to, tt.n, tt.wraps, goalPositions); got != tt.want {
+				t.Errorf("lineConflicts() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSolveWithOptions_Wrap(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   []int
+		rows    int
+		cols    int
+		wrap    Wrap
+		locked  []bool
+		wantErr error
+	}{
+		{"3x3 cylinder swap", []int{2, 1, 3, 4, 5, 6, 7, 8, 9}, 3, 3, Cylinder, nil, nil},
+		{"3x3 torus", []int{9, 2, 3, 4, 5, 6, 7, 8, 1}, 3, 3, Torus, nil, nil},
+		{"3x3 torus scrambled", []int{8, 6, 7, 2, 5, 4, 3, 9, 1}, 3, 3, Torus, nil, nil},
+		{"2x4 cylinder", []int{8, 7, 1, 5, 2, 3, 4, 6}, 2, 4, Cylinder, nil, nil},
+		{"3x4 cylinder", []int{3, 1, 2, 12, 7, 6, 4, 8, 5, 10, 11, 9}, 3, 4, Cylinder, nil, nil},
+		{"locked on a torus", []int{2, 1, 3, 4, 5, 6, 7, 8, 9}, 3, 3, Torus, lockedMask(9, 4), nil},
+		{"4x4 torus swap", []int{2, 1, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, 4, 4, Torus, nil, ErrUnsolvable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goal := StandardGoal(tt.rows, tt.cols)
+			path, err := SolveWithOptions(context.Background(), tt.start, goal, tt.rows, tt.cols, SolveOptions{Wrap: tt.wrap, Locked: tt.locked})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SolveWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			g := wrappedGrid(tt.rows, tt.cols, tt.wrap, tt.locked)
+			for i := 1; i < len(path); i++ {
+				if !slices.Contains(g.adj[path[i-1]], path[i]) {
+					t.Fatalf("path %v moves the blank from %d to %d, which are not neighbors", path, path[i-1], path[i])
+				}
+			}
+			if got := playPath(tt.start, path); !slices.Equal(got, goal) {
+				t.Fatalf("path %v ends at %v", path, got)
+			}
+			if want := lockedDistance(g, tt.start, goal); len(path)-1 != want {
+				t.Errorf("path takes %d moves, want %d", len(path)-1, want)
+			}
+		})
+	}
+}